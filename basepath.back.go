@@ -0,0 +1,66 @@
+//go:build !wasm
+
+package site
+
+import (
+	"regexp"
+
+	"github.com/tinywasm/client"
+)
+
+// rootRefs matches root-absolute URL attributes up to the end of the first
+// path segment: "/style.css", "/users/7", "/"; "//cdn..." is the
+// protocol-relative case withBasePath leaves alone.
+var rootRefs = regexp.MustCompile(`(\s(?:href|src|action|poster|xlink:href)=["'])/([^/"'?#]*)([/"'?#])`)
+
+// withBasePath prefixes the root-absolute URLs of page that point at the
+// site itself with the base path: the root, the asset routes, /batch and
+// the /name/... paths of registered handlers. Other root URLs (/login...)
+// belong to the host and are left unchanged.
+func (s *Site) withBasePath(page []byte) []byte {
+	if s.config.BasePath == "" {
+		return page
+	}
+	own := map[string]bool{"": true, "batch": true}
+	for _, route := range assetRoutes {
+		own[route[1:]] = true
+	}
+	for _, h := range s.handler.handlers {
+		own[h.(interface{ HandlerName() string }).HandlerName()] = true
+	}
+	return rootRefs.ReplaceAllFunc(page, func(m []byte) []byte {
+		sub := rootRefs.FindSubmatch(m)
+		segment, end := string(sub[2]), string(sub[3])
+		if !own[segment] || (segment == "" && end == "/") {
+			return m
+		}
+		return []byte(string(sub[1]) + s.config.BasePath + "/" + segment + end)
+	})
+}
+
+// pageEdit returns the edit applied to the page: head inserted before
+// </head>, then URLs moved under the base path. It is nil when there is
+// nothing to change.
+func (s *Site) pageEdit(head string) func(page []byte) []byte {
+	if head == "" && s.config.BasePath == "" {
+		return nil
+	}
+	return func(page []byte) []byte {
+		return s.withBasePath(insertHead(page, head))
+	}
+}
+
+// clientInitJS returns the script.js generator: the wasm_exec runtime and
+// a loader fetching client.wasm below the base path.
+func (s *Site) clientInitJS(j *client.Javascript) func() (string, error) {
+	if s.config.BasePath == "" {
+		return func() (string, error) { return j.GetSSRClientInitJS() }
+	}
+	footer := `
+		const go = new Go();
+		WebAssembly.instantiateStreaming(fetch("` + s.config.BasePath + `/client.wasm"), go.importObject).then((result) => {
+			go.run(result.instance);
+		});
+	`
+	return func() (string, error) { return j.GetSSRClientInitJS("", footer) }
+}
@@ -3,28 +3,126 @@
 package site
 
 import (
+	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/tinywasm/assetmin"
-	"github.com/tinywasm/fmt"
+	"github.com/tinywasm/client"
+	"github.com/tinywasm/site/linkcheck"
 )
 
 const staticBuildFlag = "--ssr-static-build"
 
+// BuildStatic applies Site.BuildStatic to the default site.
+func BuildStatic(outputDir string) error {
+	return defaultSite.BuildStatic(outputDir)
+}
+
 // BuildStatic renders all registered modules and writes the output
 // to outputDir as static HTML/CSS/JS/SVG files using assetmin.
-func BuildStatic(outputDir string) error {
-	am := assetmin.NewAssetMin(&assetmin.Config{
+// With SetPrecompress(true) every text and wasm file also gets a .gz sibling.
+// Structured data and, with SetContentSecurityPolicy, a matching CSP <meta>
+// tag are added to the page head.
+// With SetBaseURL sitemap.xml and robots.txt are generated as well.
+// With SetLinkCheck(true) broken internal references fail the build.
+// When outputDir already holds client.wasm (see cmd/sitebuild), script.js
+// includes the wasm_exec runtime and the code that loads it.
+func (s *Site) BuildStatic(outputDir string) error {
+	if err := s.checkConfig(); err != nil {
+		return err
+	}
+	ac := &assetmin.Config{
 		OutputDir: outputDir,
-	})
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "client.wasm")); err == nil {
+		jsHandler := client.NewJavascriptFromArgs()
+		ac.GetSSRClientInitJS = s.clientInitJS(jsHandler)
+	}
+	am := assetmin.NewAssetMin(ac)
 	am.EnsureOutputDirectoryExists()
-	if err := ssrBuild(am); err != nil {
+	if err := s.ssrBuild(am); err != nil {
 		return err
 	}
 	am.SetBuildOnDisk(true)
+	if err := s.writeStaticIndex(am, outputDir); err != nil {
+		return err
+	}
+	if err := s.writeSitemap(outputDir); err != nil {
+		return err
+	}
+	if s.config.LinkCheck {
+		if err := s.checkLinks(outputDir); err != nil {
+			return err
+		}
+	}
+	if s.config.Precompress {
+		if err := precompressDir(outputDir); err != nil {
+			return err
+		}
+	}
+	return reportDir(s.lastReport, outputDir)
+}
+
+// writeStaticIndex rewrites index.html with head additions assetmin cannot
+// express (structured data, the CSP <meta> tag) and the base path. It is a
+// no-op when there is nothing to change.
+func (s *Site) writeStaticIndex(am *assetmin.AssetMin, outputDir string) error {
+	edit := s.pageEdit(s.pageHead())
+	if edit == nil && s.config.ContentSecurityPolicy == "" {
+		return nil
+	}
+	routes := http.NewServeMux()
+	am.RegisterRoutes(routes)
+	page, err := renderRoute(routes, "/")
+	if err != nil {
+		return err
+	}
+	if edit != nil {
+		page = edit(page)
+	}
+	if s.config.ContentSecurityPolicy != "" {
+		page = insertHead(page, metaCSP(s.pageCSP(page)))
+	}
+	return os.WriteFile(filepath.Join(outputDir, "index.html"), page, 0644)
+}
+
+// checkLinks verifies the generated pages against the registered modules.
+func (s *Site) checkLinks(outputDir string) error {
+	modules := make([]string, 0, len(s.handler.registeredModules))
+	for _, m := range s.handler.registeredModules {
+		modules = append(modules, m.name)
+	}
+	problems, err := linkcheck.DirAt(outputDir, s.config.BasePath, modules)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return problems
+	}
 	return nil
 }
 
+// writeSitemap writes sitemap.xml and robots.txt when SetBaseURL was called.
+func (s *Site) writeSitemap(outputDir string) error {
+	if !s.sitemapEnabled() {
+		return nil
+	}
+	sitemap, err := s.sitemapXML()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "sitemap.xml"), sitemap, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "robots.txt"), s.robotsTxt(), 0644)
+}
+
+// AutoBuild applies Site.AutoBuild to the default site.
+func AutoBuild() bool {
+	return defaultSite.AutoBuild()
+}
+
 // AutoBuild checks os.Args for --ssr-static-build <dir>.
 // If found, it runs BuildStatic and returns true so the caller should exit.
 // Designed to be called early in main(), after RegisterHandlers.
@@ -35,16 +133,43 @@ func BuildStatic(outputDir string) error {
 //	if site.AutoBuild() {
 //	    return
 //	}
-func AutoBuild() bool {
+//
+// With --ssr-report <file> the BuildReport is also written to file as JSON.
+// With --ssr-routes <file> s.Routes() is written to file as JSON instead of
+// building (see `sitebuild routes`).
+func (s *Site) AutoBuild() bool {
+	if routesFile := argValue(routesFlag); routesFile != "" {
+		if err := s.writeRoutesFile(routesFile); err != nil {
+			s.log().Error("site: ssr-routes failed", "file", routesFile, "err", err)
+			os.Exit(1)
+		}
+		return true
+	}
 	for i, arg := range os.Args {
 		if arg == staticBuildFlag && i+1 < len(os.Args) {
 			outputDir := os.Args[i+1]
-			if err := BuildStatic(outputDir); err != nil {
-				fmt.Println("ssr-static-build error:", err)
+			if err := s.BuildStatic(outputDir); err != nil {
+				s.log().Error("site: ssr-static-build failed", "dir", outputDir, "err", err)
 				os.Exit(1)
 			}
+			if reportFile := argValue(buildReportFlag); reportFile != "" {
+				if err := writeReportFile(s.lastReport, reportFile); err != nil {
+					s.log().Error("site: ssr-report failed", "file", reportFile, "err", err)
+					os.Exit(1)
+				}
+			}
 			return true
 		}
 	}
 	return false
 }
+
+// argValue returns the value following flag in os.Args, or "".
+func argValue(flag string) string {
+	for i, arg := range os.Args {
+		if arg == flag && i+1 < len(os.Args) {
+			return os.Args[i+1]
+		}
+	}
+	return ""
+}
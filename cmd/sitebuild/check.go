@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tinywasm/site/linkcheck"
+)
+
+// runCheck implements `sitebuild check [build flags] <package-path>`: it
+// builds the site and checks the generated HTML with package linkcheck.
+func runCheck(b builder, args []string) int {
+	o, err := parseArgs(args)
+	if errors.Is(err, errUsage) {
+		printUsage()
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
+		return 1
+	}
+
+	tmpDir, err := os.MkdirTemp("", "sitebuild-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: failed to create temp dir:", err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	failed := false
+	for i, t := range o.targets {
+		rep, err := newProject(b, o, i, tmpDir).build()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild:", err)
+			return 1
+		}
+		var modules []string
+		var base string
+		if rep != nil {
+			base = rep.BasePath
+			for _, m := range rep.Modules {
+				modules = append(modules, m.Name)
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, "sitebuild: module names unknown, hash links only match element ids")
+		}
+		problems, err := linkcheck.DirAt(t.outDir, base, modules)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild: check:", err)
+			return 1
+		}
+		for _, p := range problems {
+			p.File = filepath.Join(t.outDir, p.File)
+			fmt.Fprintln(os.Stderr, p.String())
+		}
+		if len(problems) > 0 {
+			fmt.Fprintln(os.Stderr, "sitebuild:", len(problems), "broken reference(s) in", t.outDir)
+			failed = true
+			continue
+		}
+		fmt.Println("sitebuild: all links resolve in", t.outDir)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
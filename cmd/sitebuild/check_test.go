@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestRunCheck(t *testing.T) {
+	// testReport registers module "home"
+	ok := &fakeBuilder{report: testReport, pages: map[string]string{
+		"index.html": `<a href="#home">home</a><link rel="stylesheet" href="/style.css">`,
+		"style.css":  "a{}",
+	}}
+	if code := runCheck(ok, []string{"--out", t.TempDir(), "./app"}); code != 0 {
+		t.Errorf("clean site: exit code = %d, want 0", code)
+	}
+
+	broken := &fakeBuilder{report: testReport, pages: map[string]string{
+		"index.html": "<a href=\"#home\">home</a>\n<a href=\"#homepage\">renamed</a>",
+	}}
+	if code := runCheck(broken, []string{"--out", t.TempDir(), "./app"}); code != 1 {
+		t.Errorf("broken link: exit code = %d, want 1", code)
+	}
+}
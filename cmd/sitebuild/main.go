@@ -2,74 +2,362 @@
 //
 // Usage:
 //
-//	sitebuild [--out <dir>] <package-path>
+//	sitebuild [--config <file>] [--out <dir>] [--client <pkg> | --no-client] [--tinygo]
+//	          [--tags <tags>] [--ldflags <flags>] [--trimpath] [--env KEY=VAL]...
+//	          [--budget-module <size>] [--budget-bundle <size>] [--budget-wasm <size>]
+//	          <package-path>...
 //
 // The package at <package-path> must call site.AutoBuild() early in its main().
-// sitebuild compiles the package, runs it with --ssr-static-build <dir>,
-// and exits with the same code as the subprocess.
+// sitebuild compiles the wasm client (GOOS=js GOARCH=wasm, or TinyGo with
+// --tinygo) into <dir>/client.wasm, compiles the package, runs it with
+// --ssr-static-build <dir> so script.js embeds the wasm loader, prints a
+// summary of the build report and exits non-zero when the build fails or a
+// size budget is exceeded: --budget-module per module, --budget-bundle per
+// output file other than client.wasm, --budget-wasm for client.wasm. Sizes
+// accept B, KB and MB suffixes.
+//
+// The client package defaults to <package-path>, whose wasm-tagged files
+// (e.g. client.go with //go:build wasm) form the client entrypoint. It is
+// skipped when no file of that package is built only for the browser: a
+// _js.go or _wasm.go suffix, or a //go:build line requiring js or wasm.
+//
+// --tags, --ldflags and --trimpath are passed to go build; --env entries are
+// set for the go tool and the render subprocess. With several packages each
+// one is generated into <dir>/<package base name>. --config reads the same
+// options, plus a per-package list, from a JSON file; flags override it.
 //
 // Example:
 //
-//	sitebuild --out dist/ ./cmd/myapp
+//	sitebuild --out dist/ --budget-bundle 200KB --budget-wasm 2MB ./cmd/myapp
+//
+// To preview the output like a static host would (default localhost:8080, dist):
+//
+//	sitebuild serve [--addr <addr>] [<dir>]
+//
+// To rebuild on every change to the Go sources and module assets of the local
+// packages the site depends on, serve the output and reload open browsers:
+//
+//	sitebuild watch [--addr <addr>] [build flags] <package-path>
+//
+// To list every registered handler with its mode, CRUD verbs, roles and
+// asset contributions, as a table or JSON:
+//
+//	sitebuild routes [--json] [build flags] <package-path>
+//
+// To build and verify that every internal link (#module/..., path links),
+// sprite <use> reference and asset URL in the generated HTML resolves,
+// reporting file:line for each broken one:
+//
+//	sitebuild check [build flags] <package-path>
+//
+// To scaffold a site (modules/, web/server.go, web/client.go and a home
+// module) or a module wired into modules.Init() — shared struct, back.go
+// CRUD handlers, wasm front.go component and a test:
+//
+//	sitebuild new site <dir> [--module <path>]
+//	sitebuild new module <name> [--ssr | --spa] [--crud=<letters>] [--dir <modules dir>]
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"go/build/constraint"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // builder is the interface for compiling and running a Go binary.
 // Defined for testability — the real implementation uses os/exec.
 type builder interface {
-	Build(pkg, outBin string) error
-	Run(bin string, args []string) error
+	Build(pkg, outBin string, f buildFlags) error
+	// BuildWasm compiles pkg for the browser. It returns errNoClient when pkg
+	// has no file built only for GOOS=js GOARCH=wasm.
+	BuildWasm(pkg, outWasm string, tinygo bool, f buildFlags) error
+	// Run executes bin with args; env holds extra KEY=VAL entries.
+	Run(bin string, args []string, env []string) error
+	// Deps lists the directories of the local (main or path-replaced) packages
+	// pkg depends on, including itself, for the native or the wasm target.
+	Deps(pkg string, wasm bool, f buildFlags) ([]string, error)
 }
 
+var errNoClient = errors.New("no wasm client files")
+
 // realBuilder is the production implementation of builder.
 type realBuilder struct{}
 
-func (r *realBuilder) Build(pkg, outBin string) error {
-	cmd := exec.Command("go", "build", "-o", outBin, pkg)
+func (r *realBuilder) Build(pkg, outBin string, f buildFlags) error {
+	args := append([]string{"build", "-o", outBin}, f.goArgs()...)
+	cmd := exec.Command("go", append(args, pkg)...)
+	cmd.Env = f.environ()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (r *realBuilder) Run(bin string, args []string) error {
-	cmd := exec.Command(bin, args...)
+func (r *realBuilder) BuildWasm(pkg, outWasm string, tinygo bool, f buildFlags) error {
+	env := f.environ("GOOS=js", "GOARCH=wasm")
+	var tags []string
+	if f.tags != "" {
+		tags = []string{"-tags", f.tags}
+	}
+	list := exec.Command("go", append(append([]string{"list", "-e", "-f", "{{.Dir}}{{range .GoFiles}}\n{{.}}{{end}}"}, tags...), pkg)...)
+	list.Env = env
+	out, err := list.Output()
+	if err != nil {
+		return err
+	}
+	if !hasWasmFile(strings.Split(strings.TrimSpace(string(out)), "\n"), strings.Split(f.tags, ",")) {
+		return errNoClient
+	}
+
+	args := append([]string{"build", "-o", outWasm}, f.goArgs()...)
+	cmd := exec.Command("go", append(args, pkg)...)
+	if tinygo {
+		// TinyGo has no -trimpath
+		args = append([]string{"build", "-o", outWasm, "-target", "wasm", "-no-debug"}, tags...)
+		if f.ldflags != "" {
+			args = append(args, "-ldflags", f.ldflags)
+		}
+		cmd = exec.Command("tinygo", append(args, pkg)...)
+	}
+	cmd.Env = env
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func run(b builder, args []string) int {
-	outDir := "dist"
-	pkg := ""
-
-	// Parse args: sitebuild [--out <dir>] <package-path>
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--out", "-out", "-o":
-			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "sitebuild: --out requires a directory argument")
-				return 1
+// hasWasmFile reports whether the package listed as its directory followed
+// by its GoFiles has a file built only for the browser, so untagged
+// packages are not mistaken for a client.
+func hasWasmFile(list, tags []string) bool {
+	if len(list) < 2 {
+		return false
+	}
+	for _, name := range list[1:] {
+		src, err := os.ReadFile(filepath.Join(list[0], name))
+		if err == nil && wasmOnly(name, src, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// wasmOnly reports whether the Go file name with contents src is excluded
+// from native builds: a _js.go or _wasm.go suffix, or a //go:build line that
+// holds for GOOS=js GOARCH=wasm and not without them.
+func wasmOnly(name string, src []byte, tags []string) bool {
+	base := strings.TrimSuffix(name, ".go")
+	if strings.HasSuffix(base, "_js") || strings.HasSuffix(base, "_wasm") {
+		return true
+	}
+	expr := buildConstraint(src)
+	if expr == nil {
+		return false
+	}
+	eval := func(wasm bool) bool {
+		return expr.Eval(func(tag string) bool {
+			switch {
+			case tag == "js" || tag == "wasm":
+				return wasm
+			case tag == "gc" || strings.HasPrefix(tag, "go1."):
+				return true
 			}
-			i++
-			outDir = args[i]
-		default:
-			if pkg != "" {
-				fmt.Fprintln(os.Stderr, "sitebuild: unexpected argument:", args[i])
-				return 1
+			return slices.Contains(tags, tag)
+		})
+	}
+	return eval(true) && !eval(false)
+}
+
+// buildConstraint returns the //go:build expression of src, or nil.
+func buildConstraint(src []byte) constraint.Expr {
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if constraint.IsGoBuild(line) {
+			expr, err := constraint.Parse(line)
+			if err != nil {
+				return nil
 			}
-			pkg = args[i]
+			return expr
+		}
+		if strings.HasPrefix(line, "package ") {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *realBuilder) Run(bin string, args []string, env []string) error {
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// depsTemplate prints the directory of every dependency that lives outside
+// the module cache: the main module and modules replaced by a local path.
+const depsTemplate = `{{with .Module}}{{if or .Main (and .Replace (not .Replace.Version))}}{{$.Dir}}{{end}}{{end}}`
+
+func (r *realBuilder) Deps(pkg string, wasm bool, f buildFlags) ([]string, error) {
+	args := []string{"list", "-e", "-deps", "-f", depsTemplate}
+	if f.tags != "" {
+		args = append(args, "-tags", f.tags)
+	}
+	cmd := exec.Command("go", append(args, pkg)...)
+	cmd.Env = f.environ()
+	if wasm {
+		cmd.Env = f.environ("GOOS=js", "GOARCH=wasm")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs, nil
+}
+
+// project runs the build steps for one target.
+type project struct {
+	b          builder
+	o          *options
+	t          target
+	bin        string // compiled server
+	reportFile string
+	withClient bool // client.wasm was built and its loader is expected
+}
+
+// newProject prepares the build of o.targets[i]; tmpDir holds its binary.
+func newProject(b builder, o *options, i int, tmpDir string) *project {
+	n := strconv.Itoa(i)
+	return &project{
+		b:          b,
+		o:          o,
+		t:          o.targets[i],
+		bin:        filepath.Join(tmpDir, "sitebuild_app"+n),
+		reportFile: filepath.Join(tmpDir, "report"+n+".json"),
+	}
+}
+
+// client returns the wasm client package and whether it was set explicitly.
+func (p *project) client() (string, bool) {
+	if p.t.clientPkg != "" {
+		return p.t.clientPkg, true
+	}
+	return p.t.pkg, false
+}
+
+// buildClient compiles the wasm client into the output dir, so the static
+// build embeds its loader in script.js.
+func (p *project) buildClient() error {
+	p.withClient = false
+	if p.o.noClient {
+		return nil
+	}
+	pkg, explicit := p.client()
+	if err := os.MkdirAll(p.t.outDir, 0755); err != nil {
+		return errors.New("failed to create output dir: " + err.Error())
+	}
+	// A stale client.wasm would otherwise survive a failed or skipped build
+	wasmFile := filepath.Join(p.t.outDir, wasmName)
+	os.Remove(wasmFile)
+	fmt.Println("sitebuild: compiling wasm client", pkg)
+	err := p.b.BuildWasm(pkg, wasmFile, p.o.tinygo, p.o.flags)
+	switch {
+	case errors.Is(err, errNoClient) && !explicit:
+		fmt.Println("sitebuild: no wasm client in", pkg+", skipping")
+	case err != nil:
+		return errors.New("wasm build failed: " + err.Error())
+	default:
+		p.withClient = true
+	}
+	return nil
+}
+
+// buildServer compiles the package that calls site.AutoBuild.
+func (p *project) buildServer() error {
+	fmt.Println("sitebuild: compiling", p.t.pkg)
+	if err := p.b.Build(p.t.pkg, p.bin, p.o.flags); err != nil {
+		return errors.New("build failed: " + err.Error())
+	}
+	return nil
+}
+
+// generate runs the server with --ssr-static-build; -wasmsize_mode selects
+// the wasm_exec runtime matching the client compiler. The report is nil when
+// the binary did not write one, which is an error when budgets are set.
+func (p *project) generate() (*buildReport, error) {
+	fmt.Println("sitebuild: generating static site to", p.t.outDir)
+	os.Remove(p.reportFile)
+	runArgs := []string{"--ssr-static-build", p.t.outDir, "--ssr-report", p.reportFile}
+	if p.withClient {
+		mode := "L"
+		if p.o.tinygo {
+			mode = "S"
 		}
+		runArgs = append(runArgs, "-wasmsize_mode="+mode)
+	}
+	if err := p.b.Run(p.bin, runArgs, p.o.flags.env); err != nil {
+		return nil, errors.New("static build failed: " + err.Error())
 	}
+	if p.withClient {
+		if err := verifyClientLoader(p.t.outDir); err != nil {
+			return nil, err
+		}
+	}
+	rep, err := readReport(p.reportFile)
+	if err != nil {
+		if p.o.budgets != (budgets{}) {
+			return nil, errors.New("no build report to check budgets against (site.AutoBuild too old?): " + err.Error())
+		}
+		fmt.Fprintln(os.Stderr, "sitebuild: no build report (site.AutoBuild too old?):", err)
+		return nil, nil
+	}
+	return rep, nil
+}
 
-	if pkg == "" {
-		fmt.Fprintln(os.Stderr, "Usage: sitebuild [--out <dir>] <package-path>")
-		fmt.Fprintln(os.Stderr, "Example: sitebuild --out dist/ ./cmd/myapp")
+// build runs every step: client, server and static generation.
+func (p *project) build() (*buildReport, error) {
+	if err := p.buildClient(); err != nil {
+		return nil, err
+	}
+	if err := p.buildServer(); err != nil {
+		return nil, err
+	}
+	return p.generate()
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: sitebuild [--config <file>] [--out <dir>] [--client <pkg> | --no-client] [--tinygo]")
+	fmt.Fprintln(os.Stderr, "                 [--tags <tags>] [--ldflags <flags>] [--trimpath] [--env KEY=VAL]...")
+	fmt.Fprintln(os.Stderr, "                 [--budget-module <size>] [--budget-bundle <size>] [--budget-wasm <size>]")
+	fmt.Fprintln(os.Stderr, "                 <package-path>...")
+	fmt.Fprintln(os.Stderr, "       sitebuild serve [--addr <addr>] [<dir>]")
+	fmt.Fprintln(os.Stderr, "       sitebuild watch [--addr <addr>] [build flags] <package-path>")
+	fmt.Fprintln(os.Stderr, "       sitebuild routes [--json] [build flags] <package-path>")
+	fmt.Fprintln(os.Stderr, "       sitebuild check [build flags] <package-path>")
+	fmt.Fprintln(os.Stderr, "       sitebuild new site <dir> [--module <path>]")
+	fmt.Fprintln(os.Stderr, "       sitebuild new module <name> [--ssr | --spa] [--crud=<letters>] [--dir <modules dir>]")
+	fmt.Fprintln(os.Stderr, "Example: sitebuild --out dist/ ./cmd/myapp")
+}
+
+func run(b builder, args []string) int {
+	o, err := parseArgs(args)
+	if errors.Is(err, errUsage) {
+		printUsage()
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
 		return 1
 	}
 
@@ -81,26 +369,55 @@ func run(b builder, args []string) int {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	outBin := filepath.Join(tmpDir, "sitebuild_app")
+	for i, t := range o.targets {
+		rep, err := newProject(b, o, i, tmpDir).build()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild:", err)
+			return 1
+		}
 
-	// Step 1: compile
-	fmt.Println("sitebuild: compiling", pkg)
-	if err := b.Build(pkg, outBin); err != nil {
-		fmt.Fprintln(os.Stderr, "sitebuild: build failed:", err)
-		return 1
-	}
+		// Summarize the build report and check budgets
+		if rep != nil {
+			printSummary(os.Stdout, rep)
+			if violations := o.budgets.check(rep); len(violations) > 0 {
+				for _, v := range violations {
+					fmt.Fprintln(os.Stderr, "sitebuild: budget exceeded:", v)
+				}
+				return 1
+			}
+		}
 
-	// Step 2: run with --ssr-static-build flag
-	fmt.Println("sitebuild: generating static site to", outDir)
-	if err := b.Run(outBin, []string{"--ssr-static-build", outDir}); err != nil {
-		fmt.Fprintln(os.Stderr, "sitebuild: static build failed:", err)
-		return 1
+		fmt.Println("sitebuild: done →", t.outDir)
 	}
-
-	fmt.Println("sitebuild: done →", outDir)
 	return 0
 }
 
+// verifyClientLoader checks that script.js loads the compiled client.
+func verifyClientLoader(outDir string) error {
+	js, err := os.ReadFile(filepath.Join(outDir, "script.js"))
+	if err != nil {
+		return errors.New("script.js missing from " + outDir + ": " + err.Error())
+	}
+	if !bytes.Contains(js, []byte("client.wasm")) || !bytes.Contains(js, []byte("importObject")) {
+		return errors.New("script.js does not load client.wasm (stale script.js in " + outDir + "?)")
+	}
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(&realBuilder{}, os.Args[2:]))
+		case "routes":
+			os.Exit(runRoutes(&realBuilder{}, os.Args[2:], os.Stdout))
+		case "check":
+			os.Exit(runCheck(&realBuilder{}, os.Args[2:]))
+		case "new":
+			os.Exit(runNew(os.Args[2:]))
+		}
+	}
 	os.Exit(run(&realBuilder{}, os.Args[1:]))
 }
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeBuilder records calls and writes report and routes as the
+// --ssr-report and --ssr-routes files.
+// With client set, BuildWasm produces a wasm file and Run a script.js that
+// loads it; otherwise the package has no wasm client.
+type fakeBuilder struct {
+	serverDeps []string
+	clientDeps []string
+	builds     int
+	wasmBuilds int
+	runs       int
+	routes     string            // written as the --ssr-routes file
+	pages      map[string]string // written to the output dir by Run
+	report     string
+	client     bool
+	wasmPkg    string
+	wasmTiny   bool
+	runArgs    []string
+	noLoader   bool // Run leaves script.js without the loader
+	flags      buildFlags
+	runEnv     []string
+	outDirs    []string // output dir of every Run
+	onBuild    func()   // called by Build, e.g. to edit sources mid-build
+}
+
+func (f *fakeBuilder) Build(pkg, outBin string, flags buildFlags) error {
+	f.builds++
+	f.flags = flags
+	if f.onBuild != nil {
+		f.onBuild()
+	}
+	return nil
+}
+
+func (f *fakeBuilder) Deps(pkg string, wasm bool, flags buildFlags) ([]string, error) {
+	if wasm {
+		return f.clientDeps, nil
+	}
+	return f.serverDeps, nil
+}
+
+func (f *fakeBuilder) BuildWasm(pkg, outWasm string, tinygo bool, flags buildFlags) error {
+	f.wasmBuilds++
+	f.wasmPkg, f.wasmTiny = pkg, tinygo
+	if !f.client {
+		return errNoClient
+	}
+	return os.WriteFile(outWasm, []byte("\x00asm"), 0644)
+}
+
+func (f *fakeBuilder) Run(bin string, args []string, env []string) error {
+	f.runs++
+	f.runArgs = args
+	f.runEnv = env
+	f.outDirs = append(f.outDirs, args[1])
+	for name, body := range f.pages {
+		if err := os.WriteFile(filepath.Join(args[1], name), []byte(body), 0644); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(args[1], "client.wasm")); err == nil {
+		js := `'use strict';const go=new Go();WebAssembly.instantiateStreaming(fetch("client.wasm"),go.importObject)`
+		if f.noLoader {
+			js = `'use strict';`
+		}
+		if err := os.WriteFile(filepath.Join(args[1], "script.js"), []byte(js), 0644); err != nil {
+			return err
+		}
+	}
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--ssr-routes" {
+			return os.WriteFile(args[i+1], []byte(f.routes), 0644)
+		}
+		if args[i] == "--ssr-report" && f.report != "" {
+			return os.WriteFile(args[i+1], []byte(f.report), 0644)
+		}
+	}
+	return nil
+}
+
+const testReport = `{"duration_ms":1.5,"modules":[{"name":"home","mode":"ssr","html_bytes":900,"css_bytes":200,"js_bytes":0}],
+"files":[{"path":"index.html","bytes":4096},{"path":"index.html.gz","bytes":99999}]}`
+
+func TestRunWithinBudget(t *testing.T) {
+	fb := &fakeBuilder{report: testReport}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-module", "2KB", "--budget-bundle", "8KB", "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+}
+
+func TestRunModuleBudgetExceeded(t *testing.T) {
+	fb := &fakeBuilder{report: testReport}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-module", "1KB", "./app"}); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+}
+
+func TestRunBundleBudgetExceeded(t *testing.T) {
+	fb := &fakeBuilder{report: testReport}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-bundle", "4000", "./app"}); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+}
+
+// A typical Go wasm client is well over the bundle budget of the package doc
+// example; it only counts against --budget-wasm.
+const wasmReport = `{"duration_ms":1.5,"modules":[{"name":"home","mode":"ssr","html_bytes":900,"css_bytes":200,"js_bytes":0}],
+"files":[{"path":"index.html","bytes":4096},{"path":"client.wasm","bytes":1572864},{"path":"client.wasm.gz","bytes":524288}]}`
+
+func TestRunDocumentedBudgetExample(t *testing.T) {
+	fb := &fakeBuilder{report: wasmReport}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-bundle", "200KB", "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0: client.wasm is outside the bundle budget", code)
+	}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-bundle", "200KB", "--budget-wasm", "2MB", "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0 within the wasm budget", code)
+	}
+	if code := run(fb, []string{"--out", t.TempDir(), "--budget-wasm", "1MB", "./app"}); code != 1 {
+		t.Fatalf("exit code = %d, want 1 over the wasm budget", code)
+	}
+}
+
+func TestRunBudgetWithoutReport(t *testing.T) {
+	if code := run(&fakeBuilder{}, []string{"--out", t.TempDir(), "--budget-bundle", "8KB", "./app"}); code != 1 {
+		t.Fatalf("exit code = %d, want 1 when budgets cannot be checked", code)
+	}
+	if code := run(&fakeBuilder{}, []string{"--out", t.TempDir(), "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0 without budgets", code)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"512": 512, "20KB": 20 << 10, "1.5MB": 3 << 19, "10b": 10}
+	for in, want := range cases {
+		got, err := parseSize(in)
+		if err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseSize("big"); err == nil {
+		t.Error("parseSize(\"big\") should fail")
+	}
+}
+
+func TestWasmOnly(t *testing.T) {
+	cases := []struct {
+		name, src string
+		want      bool
+	}{
+		{"client.go", "//go:build wasm\n\npackage main\n", true},
+		{"client.go", "// Client entry.\n//go:build js && wasm\n\npackage main\n", true},
+		{"client.go", "//go:build wasm && prod\n\npackage main\n", true},
+		{"client.go", "//go:build wasm && dev\n\npackage main\n", false},
+		{"client_js.go", "package main\n", true},
+		{"main.go", "package main\n", false},
+		{"main.go", "//go:build !wasm\n\npackage main\n", false},
+		{"main.go", "//go:build go1.21\n\npackage main\n", false},
+		{"main.go", "package main\n\n//go:build wasm\n", false},
+	}
+	for _, c := range cases {
+		if got := wasmOnly(c.name, []byte(c.src), []string{"prod"}); got != c.want {
+			t.Errorf("wasmOnly(%q, %q) = %v, want %v", c.name, c.src, got, c.want)
+		}
+	}
+}
+
+func TestRunBuildsClient(t *testing.T) {
+	out := t.TempDir()
+	fb := &fakeBuilder{report: testReport, client: true}
+	if code := run(fb, []string{"--out", out, "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if fb.wasmPkg != "./app" {
+		t.Errorf("client package = %q, want ./app", fb.wasmPkg)
+	}
+	if !slices.Contains(fb.runArgs, "-wasmsize_mode=L") {
+		t.Errorf("run args %v should select the Go wasm runtime", fb.runArgs)
+	}
+	if _, err := os.Stat(filepath.Join(out, "client.wasm")); err != nil {
+		t.Errorf("client.wasm not in output: %v", err)
+	}
+}
+
+func TestRunWithoutClient(t *testing.T) {
+	out := t.TempDir()
+	os.WriteFile(filepath.Join(out, "client.wasm"), []byte("stale"), 0644)
+	fb := &fakeBuilder{report: testReport}
+	if code := run(fb, []string{"--out", out, "./app"}); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if slices.Contains(fb.runArgs, "-wasmsize_mode=L") {
+		t.Error("wasm runtime selected without a client")
+	}
+	if _, err := os.Stat(filepath.Join(out, "client.wasm")); err == nil {
+		t.Error("stale client.wasm should be removed")
+	}
+
+	// An explicit client package must exist
+	if code := run(&fakeBuilder{report: testReport}, []string{"--out", out, "--client", "./web", "./app"}); code != 1 {
+		t.Fatalf("explicit missing client: exit code = %d, want 1", code)
+	}
+}
+
+func TestRunClientLoaderMissing(t *testing.T) {
+	fb := &fakeBuilder{report: testReport, client: true, noLoader: true}
+	if code := run(fb, []string{"--out", t.TempDir(), "./app"}); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+}
+
+func TestRunPassesBuildFlags(t *testing.T) {
+	out := t.TempDir()
+	fb := &fakeBuilder{report: testReport}
+	args := []string{"--out", out, "--tags", "prod", "--ldflags", "-X main.version=1.2.0", "--trimpath", "--env", "APP_ENV=production", "./site", "./docs"}
+	if code := run(fb, args); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if fb.flags.tags != "prod" || fb.flags.ldflags != "-X main.version=1.2.0" || !fb.flags.trimpath {
+		t.Errorf("build flags = %+v", fb.flags)
+	}
+	if !slices.Equal(fb.runEnv, []string{"APP_ENV=production"}) {
+		t.Errorf("render env = %v, want [APP_ENV=production]", fb.runEnv)
+	}
+	want := []string{filepath.Join(out, "site"), filepath.Join(out, "docs")}
+	if fb.builds != 2 || !slices.Equal(fb.outDirs, want) {
+		t.Errorf("builds = %d into %v, want 2 into %v", fb.builds, fb.outDirs, want)
+	}
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// runNew implements
+//
+//	sitebuild new site <dir> [--module <path>]
+//	sitebuild new module <name> [--ssr | --spa] [--crud=<letters>] [--dir <modules dir>]
+func runNew(args []string) int {
+	if len(args) < 2 {
+		printUsage()
+		return 1
+	}
+	var err error
+	switch args[0] {
+	case "site":
+		err = runNewSite(args[1:])
+	case "module":
+		err = runNewModule(args[1:])
+	default:
+		err = errors.New("new: unknown kind " + strconv.Quote(args[0]) + ", want site or module")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
+		return 1
+	}
+	return 0
+}
+
+func runNewSite(args []string) error {
+	dir, modPath := "", ""
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--module":
+			if i+1 >= len(args) {
+				return errors.New("--module requires an import path")
+			}
+			i++
+			modPath = args[i]
+		case strings.HasPrefix(arg, "-"):
+			return errors.New("unknown flag: " + arg)
+		case dir != "":
+			return errors.New("new site takes a single directory")
+		default:
+			dir = arg
+		}
+	}
+	if dir == "" {
+		return errors.New("new site requires a directory")
+	}
+	files, err := newSite(dir, modPath)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Println("sitebuild: created", f)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		fmt.Println("sitebuild: next: cd", dir, "&& go mod tidy && sitebuild ./web")
+	}
+	return nil
+}
+
+func runNewModule(args []string) error {
+	spec := moduleSpec{ssr: true, crud: "r"}
+	modulesDir := "modules"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--ssr":
+			spec.ssr = true
+		case arg == "--spa":
+			spec.ssr = false
+		case arg == "--crud":
+			if i+1 >= len(args) {
+				return errors.New("--crud requires a subset of crud")
+			}
+			i++
+			spec.crud = args[i]
+		case strings.HasPrefix(arg, "--crud="):
+			spec.crud = strings.TrimPrefix(arg, "--crud=")
+		case arg == "--dir":
+			if i+1 >= len(args) {
+				return errors.New("--dir requires a directory")
+			}
+			i++
+			modulesDir = args[i]
+		case strings.HasPrefix(arg, "-"):
+			return errors.New("unknown flag: " + arg)
+		case spec.name != "":
+			return errors.New("new module takes a single name")
+		default:
+			spec.name = arg
+		}
+	}
+	files, err := newModule(modulesDir, spec)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Println("sitebuild: created", f)
+	}
+	fmt.Println("sitebuild: added", spec.name+".Add() to", filepath.Join(modulesDir, "init.go"))
+	return nil
+}
+
+// moduleSpec describes a generated module.
+type moduleSpec struct {
+	name string // package, directory and HandlerName
+	ssr  bool   // public read renders on the server; otherwise SPA
+	crud string // CRUD actions implemented in back.go, e.g. "r" or "crud"
+}
+
+// newSite creates a site in dir: modules/init.go with a home module, the
+// server and wasm client entrypoints in web/, and a go.mod unless dir is
+// already inside a module. It returns the created files.
+func newSite(dir, modPath string) ([]string, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, errors.New(dir + " exists and is not empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var created []string
+	root, parentPath, err := findModule(dir)
+	switch {
+	case err == nil && modPath != "":
+		return nil, errors.New(dir + " is inside module " + parentPath + "; --module only applies to a new module")
+	case err == nil:
+		if modPath, err = importPathOf(root, parentPath, dir); err != nil {
+			return nil, err
+		}
+	default:
+		if modPath == "" {
+			abs, _ := filepath.Abs(dir)
+			modPath = filepath.Base(abs)
+		}
+		goMod := "module " + modPath + "\n\ngo " + goVersion() + "\n"
+		if err := writeNew(filepath.Join(dir, "go.mod"), []byte(goMod)); err != nil {
+			return nil, err
+		}
+		created = append(created, filepath.Join(dir, "go.mod"))
+	}
+
+	data := struct{ Module string }{modPath}
+	for _, f := range []struct {
+		name string
+		tmpl *template.Template
+	}{
+		{".gitignore", gitignoreTmpl},
+		{"modules/init.go", initTmpl},
+		{"web/server.go", serverTmpl},
+		{"web/client.go", clientTmpl},
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := writeTemplate(path, f.tmpl, data); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+
+	files, err := newModule(filepath.Join(dir, "modules"), moduleSpec{name: "home", ssr: true, crud: "r"})
+	return append(created, files...), err
+}
+
+// newModule creates modulesDir/<name> and adds <name>.Add() to the Init
+// function in modulesDir/init.go. It returns the created files.
+func newModule(modulesDir string, spec moduleSpec) ([]string, error) {
+	if !validModuleName(spec.name) {
+		return nil, errors.New("invalid module name " + strconv.Quote(spec.name) + ": use a lowercase Go identifier")
+	}
+	if !validCrud(spec.crud) {
+		return nil, errors.New("--crud expects a subset of \"crud\", got " + strconv.Quote(spec.crud))
+	}
+	initFile := filepath.Join(modulesDir, "init.go")
+	if _, err := os.Stat(initFile); err != nil {
+		return nil, errors.New("no " + initFile + " (run from the site root or pass --dir)")
+	}
+	root, modPath, err := findModule(modulesDir)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(modulesDir, spec.name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, errors.New(dir + " already exists")
+	}
+	importPath, err := importPathOf(root, modPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wire first: a failure leaves nothing half-created
+	wired, err := wireModule(initFile, importPath, spec.name)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := strings.ToUpper(spec.name[:1]) + spec.name[1:]
+	data := moduleData{
+		Name:     spec.name,
+		Type:     typ,
+		Recv:     spec.name[:1],
+		Title:    typ,
+		ReadRole: "*",
+		Create:   strings.Contains(spec.crud, "c"),
+		Read:     strings.Contains(spec.crud, "r"),
+		Update:   strings.Contains(spec.crud, "u"),
+		Delete:   strings.Contains(spec.crud, "d"),
+	}
+	if !spec.ssr {
+		data.ReadRole = "a"
+	}
+	var created []string
+	for _, f := range []struct {
+		name string
+		tmpl *template.Template
+	}{
+		{spec.name + ".go", moduleTmpl},
+		{"back.go", backTmpl},
+		{"front.go", frontTmpl},
+		{spec.name + "_test.go", moduleTestTmpl},
+	} {
+		path := filepath.Join(dir, f.name)
+		if err := writeTemplate(path, f.tmpl, data); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, os.WriteFile(initFile, wired, 0644)
+}
+
+type moduleData struct {
+	Name, Type, Recv, Title string
+	ReadRole                string // AllowedRoles('r'): "*" renders SSR
+	Create, Read            bool
+	Update, Delete          bool
+}
+
+func validModuleName(name string) bool {
+	if name == "" || token.IsKeyword(name) || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func validCrud(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !strings.ContainsRune("crud", c) || strings.ContainsRune(s[:i], c) {
+			return false
+		}
+	}
+	return true
+}
+
+// wireModule returns initFile with importPath imported and <pkg>.Add()
+// appended to the slice its Init function returns.
+func wireModule(initFile, importPath, pkg string) ([]byte, error) {
+	src, err := os.ReadFile(initFile)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, initFile, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	hasSlices := false
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if path == importPath || name == pkg {
+			return nil, errors.New(initFile + " already imports " + strconv.Quote(path))
+		}
+		hasSlices = hasSlices || path == "slices" && name == "slices"
+	}
+
+	var fn *ast.FuncDecl
+	for _, d := range f.Decls {
+		if d, ok := d.(*ast.FuncDecl); ok && d.Recv == nil && d.Name.Name == "Init" {
+			fn = d
+		}
+	}
+	if fn == nil || fn.Body == nil {
+		return nil, errors.New(initFile + ": no func Init")
+	}
+	var returns []*ast.ReturnStmt
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			returns = append(returns, n)
+		}
+		return true
+	})
+	if len(returns) != 1 || len(returns[0].Results) != 1 {
+		return nil, errors.New(initFile + ": Init must have a single return statement to add " + pkg + ".Add() to")
+	}
+
+	// The returned expression is rewritten as text so the rest of the file
+	// keeps its layout and comments.
+	ret := returns[0].Results[0]
+	text := func(e ast.Expr) string {
+		return string(src[fset.Position(e.Pos()).Offset:fset.Position(e.End()).Offset])
+	}
+	parts := append(initParts(ret, text), pkg+".Add()")
+	var expr string
+	switch len(parts) {
+	case 1:
+		expr = parts[0]
+	case 2:
+		expr = "append(\n" + parts[0] + ",\n" + parts[1] + "...,\n)"
+	default:
+		expr = "slices.Concat(\n" + strings.Join(parts, ",\n") + ",\n)"
+	}
+	start, end := fset.Position(ret.Pos()).Offset, fset.Position(ret.End()).Offset
+	out := concat(src[:start], expr, src[end:])
+
+	out, err = addImport(out, importPath)
+	if err != nil || len(parts) < 3 || hasSlices {
+		return out, err
+	}
+	return addImport(out, "slices")
+}
+
+// initParts splits the slice Init returns into the expressions it
+// concatenates: nil, x, append(x, y...) and slices.Concat(x, y, ...).
+func initParts(e ast.Expr, text func(ast.Expr) string) []string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if e.Name == "nil" {
+			return nil
+		}
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.Ident); ok && fn.Name == "append" && len(e.Args) == 2 && e.Ellipsis.IsValid() {
+			return append(initParts(e.Args[0], text), text(e.Args[1]))
+		}
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Concat" && !e.Ellipsis.IsValid() {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "slices" {
+				var parts []string
+				for _, a := range e.Args {
+					parts = append(parts, text(a))
+				}
+				return parts
+			}
+		}
+	}
+	return []string{text(e)}
+}
+
+// addImport inserts an import of path into src and formats the result.
+func addImport(src []byte, path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	line := strconv.Quote(path)
+	var out []byte
+	if len(f.Imports) == 0 {
+		at := fset.Position(f.Name.End()).Offset
+		return format.Source(concat(src[:at], "\n\nimport "+line, src[at:]))
+	}
+
+	// Standard library imports go first, in their own group
+	d := f.Decls[0].(*ast.GenDecl)
+	first := fset.Position(d.Specs[0].Pos()).Offset
+	sep := "\n"
+	if isStdImport(path) && !isStdImport(strings.Trim(string(src[first:fset.Position(f.Imports[0].End()).Offset]), `"`)) {
+		sep = "\n\n"
+	}
+	switch {
+	case !d.Lparen.IsValid():
+		end := fset.Position(d.End()).Offset
+		specs := string(src[first:end]) + sep + line
+		if isStdImport(path) {
+			specs = line + sep + string(src[first:end])
+		}
+		out = concat(src[:first], "(\n"+specs+"\n)", src[end:])
+	case isStdImport(path):
+		out = concat(src[:first], line+sep, src[first:])
+	default:
+		at := fset.Position(d.Rparen).Offset
+		out = concat(src[:at], line+"\n", src[at:])
+	}
+	return format.Source(out)
+}
+
+// isStdImport reports whether path is in the standard library: its first
+// element has no dot.
+func isStdImport(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func concat(before []byte, s string, after []byte) []byte {
+	out := append([]byte{}, before...)
+	out = append(out, s...)
+	return append(out, after...)
+}
+
+// findModule walks up from dir to the nearest go.mod and returns its
+// directory and module path.
+func findModule(dir string) (root, modPath string, err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if f, err := os.Open(filepath.Join(d, "go.mod")); err == nil {
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				if p, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+					return d, strings.Trim(strings.TrimSpace(p), `"`), nil
+				}
+			}
+			return "", "", errors.New(filepath.Join(d, "go.mod") + ": no module directive")
+		}
+		if filepath.Dir(d) == d {
+			return "", "", errors.New("no go.mod in " + abs + " or its parents")
+		}
+	}
+}
+
+// importPathOf returns the import path of dir in the module at root.
+func importPathOf(root, modPath, dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return modPath, nil
+	}
+	return modPath + "/" + filepath.ToSlash(rel), nil
+}
+
+// goVersion returns the language version of the running toolchain, e.g. "1.25".
+func goVersion() string {
+	v := strings.TrimPrefix(runtime.Version(), "go")
+	if parts := strings.SplitN(v, ".", 3); len(parts) >= 2 {
+		return parts[0] + "." + parts[1]
+	}
+	return "1.22"
+}
+
+func writeTemplate(path string, t *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if filepath.Ext(path) == ".go" {
+		var err error
+		if out, err = format.Source(out); err != nil {
+			return errors.New(path + ": " + err.Error())
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeNew(path, out)
+}
+
+// writeNew writes a file that must not exist yet.
+func writeNew(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var gitignoreTmpl = template.Must(template.New(".gitignore").Parse(`dist/
+`))
+
+var initTmpl = template.Must(template.New("init.go").Parse(`package modules
+
+// Init returns the handlers of every module. ` + "`sitebuild new module`" + ` adds
+// new modules here.
+func Init() []any {
+	return nil
+}
+`))
+
+var serverTmpl = template.Must(template.New("server.go").Parse(`//go:build !wasm
+
+package main
+
+import (
+	"os"
+
+	"github.com/tinywasm/fmt"
+	"github.com/tinywasm/site"
+	"{{.Module}}/modules"
+)
+
+func main() {
+	if err := site.RegisterHandlers(modules.Init()...); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// sitebuild runs this binary with --ssr-static-build <dir>
+	if site.AutoBuild() {
+		return
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Production: configure DB, user identity, and roles before Serve
+	// (or run with APP_ENV=development):
+	// site.SetDB(&db.Adapter{DB: openDB()})
+	// site.SetUserID(func(data ...any) string { ... })
+	// site.CreateRole('a', "Admin", "Full system access")
+
+	fmt.Println("Listening on :" + port)
+	if err := site.Serve(":" + port); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+`))
+
+var clientTmpl = template.Must(template.New("client.go").Parse(`//go:build wasm
+
+package main
+
+import (
+	"github.com/tinywasm/fmt"
+	"github.com/tinywasm/site"
+	"{{.Module}}/modules"
+)
+
+func main() {
+	if err := site.RegisterHandlers(modules.Init()...); err != nil {
+		fmt.Println("Error registering handlers:", err)
+		return
+	}
+	// Mount blocks
+	if err := site.Mount("app"); err != nil {
+		fmt.Println("Error mounting site:", err)
+	}
+}
+`))
+
+var moduleTmpl = template.Must(template.New("module.go").Parse(`package {{.Name}}
+
+import "github.com/tinywasm/dom"
+
+// {{.Type}} is shared by the server (back.go) and the wasm client (front.go).
+type {{.Type}} struct {
+	ID   int
+	Name string
+}
+
+func ({{.Recv}} *{{.Type}}) HandlerName() string {
+	return "{{.Name}}"
+}
+
+func ({{.Recv}} *{{.Type}}) ModuleTitle() string {
+	return "{{.Title}}"
+}
+
+// AllowedRoles returns the role codes allowed per action ('c', 'r', 'u', 'd').
+// '*' on read renders the module on the server (SSR); specific roles leave
+// rendering to the wasm client (SPA).
+func ({{.Recv}} *{{.Type}}) AllowedRoles(action byte) []byte {
+	if action == 'r' {
+		return []byte{'{{.ReadRole}}'}
+	}
+	return []byte{'a'}
+}
+
+func ({{.Recv}} *{{.Type}}) ValidateData(action byte, data ...any) error {
+	return nil
+}
+
+func ({{.Recv}} *{{.Type}}) RenderHTML() string {
+	return ` + "`" + `<section id="{{.Name}}">
+    <h1>{{.Title}}</h1>
+    <div id="{{.Name}}-content"></div>
+</section>` + "`" + `
+}
+
+func ({{.Recv}} *{{.Type}}) GetID() string             { return "{{.Name}}-module" }
+func ({{.Recv}} *{{.Type}}) SetID(id string)           {}
+func ({{.Recv}} *{{.Type}}) Children() []dom.Component { return nil }
+
+func Add() []any {
+	return []any{&{{.Type}}{}}
+}
+`))
+
+var backTmpl = template.Must(template.New("back.go").Parse(`//go:build !wasm
+
+package {{.Name}}
+{{if .Create}}
+func ({{.Recv}} *{{.Type}}) Create(data ...any) any {
+	// TODO: persist data
+	return data
+}
+{{end}}{{if .Read}}
+func ({{.Recv}} *{{.Type}}) Read(data ...any) any {
+	// TODO: load from storage
+	return []*{{.Type}}{
+		{ID: 1, Name: "Example"},
+	}
+}
+{{end}}{{if .Update}}
+func ({{.Recv}} *{{.Type}}) Update(data ...any) any {
+	// TODO: persist changes
+	return data
+}
+{{end}}{{if .Delete}}
+func ({{.Recv}} *{{.Type}}) Delete(data ...any) any {
+	// TODO: remove from storage
+	return nil
+}
+{{end}}`))
+
+var frontTmpl = template.Must(template.New("front.go").Parse(`//go:build wasm
+
+package {{.Name}}
+
+import "github.com/tinywasm/dom"
+
+func ({{.Recv}} *{{.Type}}) OnMount() {
+	dom.Render("{{.Name}}-content", dom.P("{{.Title}} loaded"))
+}
+
+func ({{.Recv}} *{{.Type}}) OnUnmount() {}
+`))
+
+var moduleTestTmpl = template.Must(template.New("module_test.go").Parse(`//go:build !wasm
+
+package {{.Name}}
+
+import "testing"
+
+func Test{{.Type}}Handler(t *testing.T) {
+	m := &{{.Type}}{}
+	if got := m.HandlerName(); got != "{{.Name}}" {
+		t.Errorf("HandlerName() = %q, want {{.Name}}", got)
+	}
+	if got := string(m.AllowedRoles('r')); got != "{{.ReadRole}}" {
+		t.Errorf("AllowedRoles('r') = %q, want {{.ReadRole}}", got)
+	}
+	if len(Add()) != 1 {
+		t.Error("Add() should return the module")
+	}
+}
+{{if .Read}}
+func Test{{.Type}}Read(t *testing.T) {
+	items, ok := (&{{.Type}}{}).Read().([]*{{.Type}})
+	if !ok || len(items) == 0 {
+		t.Errorf("Read() = %v, want []*{{.Type}}", items)
+	}
+}
+{{end}}`))
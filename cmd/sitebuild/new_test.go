@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewSiteAndModule(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "demo")
+	if _, err := newSite(dir, "example.com/demo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"go.mod", "web/server.go", "web/client.go", "modules/home/home.go", "modules/home/back.go", "modules/home/front.go", "modules/home/home_test.go"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("%s not created: %v", f, err)
+		}
+	}
+	if _, err := newSite(dir, ""); err == nil {
+		t.Error("newSite should refuse a non-empty directory")
+	}
+
+	modules := filepath.Join(dir, "modules")
+	if _, err := newModule(modules, moduleSpec{name: "blog", crud: "cd"}); err != nil {
+		t.Fatal(err)
+	}
+	back, _ := os.ReadFile(filepath.Join(modules, "blog", "back.go"))
+	if !bytes.Contains(back, []byte("//go:build !wasm")) || !bytes.Contains(back, []byte(") Create(")) || !bytes.Contains(back, []byte(") Delete(")) || bytes.Contains(back, []byte(") Read(")) {
+		t.Errorf("back.go should implement exactly Create and Delete:\n%s", back)
+	}
+	shared, _ := os.ReadFile(filepath.Join(modules, "blog", "blog.go"))
+	if !bytes.Contains(shared, []byte("return []byte{'a'}\n\t}")) {
+		t.Errorf("SPA module should restrict read:\n%s", shared)
+	}
+	init, _ := os.ReadFile(filepath.Join(modules, "init.go"))
+	if !bytes.Contains(init, []byte(`"example.com/demo/modules/blog"`)) || !bytes.Contains(init, []byte("blog.Add()...")) {
+		t.Errorf("init.go not wired:\n%s", init)
+	}
+
+	// Every generated Go file is gofmt-clean
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && filepath.Ext(p) == ".go" {
+			src, _ := os.ReadFile(p)
+			if out, err := format.Source(src); err != nil || !bytes.Equal(out, src) {
+				t.Errorf("%s is not gofmt-clean: %v", p, err)
+			}
+		}
+		return nil
+	})
+
+	if _, err := newModule(modules, moduleSpec{name: "blog", crud: "r"}); err == nil {
+		t.Error("an existing module should be rejected")
+	}
+	for _, spec := range []moduleSpec{{name: "Blog", crud: "r"}, {name: "func", crud: "r"}, {name: "my-blog", crud: "r"}, {name: "news", crud: "rx"}, {name: "news", crud: "rr"}} {
+		if _, err := newModule(modules, spec); err == nil {
+			t.Errorf("newModule(%+v) should fail", spec)
+		}
+	}
+}
+
+func TestWireModule(t *testing.T) {
+	cases := []struct {
+		name, src, want string
+	}{
+		{"nil", "package modules\n\nfunc Init() []any {\n\treturn nil\n}\n",
+			"package modules\n\nimport \"example.com/w/modules/blog\"\n\nfunc Init() []any {\n\treturn blog.Add()\n}\n"},
+		{"append", "package modules\n\nimport \"example.com/w/modules/home\"\n\nfunc Init() []any {\n\treturn home.Add()\n}\n",
+			"package modules\n\nimport (\n\t\"example.com/w/modules/blog\"\n\t\"example.com/w/modules/home\"\n)\n\nfunc Init() []any {\n\treturn append(\n\t\thome.Add(),\n\t\tblog.Add()...,\n\t)\n}\n"},
+		{"concat", "package modules\n\nimport (\n\t\"example.com/w/modules/a\"\n\t\"example.com/w/modules/home\"\n)\n\nfunc Init() []any {\n\treturn append(\n\t\ta.Add(),\n\t\thome.Add()...,\n\t)\n}\n",
+			"package modules\n\nimport (\n\t\"slices\"\n\n\t\"example.com/w/modules/a\"\n\t\"example.com/w/modules/blog\"\n\t\"example.com/w/modules/home\"\n)\n\nfunc Init() []any {\n\treturn slices.Concat(\n\t\ta.Add(),\n\t\thome.Add(),\n\t\tblog.Add(),\n\t)\n}\n"},
+	}
+	for _, c := range cases {
+		file := filepath.Join(t.TempDir(), "init.go")
+		os.WriteFile(file, []byte(c.src), 0644)
+		got, err := wireModule(file, "example.com/w/modules/blog", "blog")
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, got, c.want)
+		}
+	}
+
+	for _, src := range []string{
+		"package modules\n\nimport \"example.com/w/modules/blog\"\n\nfunc Init() []any {\n\treturn blog.Add()\n}\n",
+		"package modules\n\nfunc Setup() []any {\n\treturn nil\n}\n",
+		"package modules\n\nfunc Init() (all []any) {\n\tif all == nil {\n\t\treturn nil\n\t}\n\treturn all\n}\n",
+	} {
+		file := filepath.Join(t.TempDir(), "init.go")
+		os.WriteFile(file, []byte(src), 0644)
+		if _, err := wireModule(file, "example.com/w/modules/blog", "blog"); err == nil || !strings.Contains(err.Error(), "init.go") {
+			t.Errorf("wireModule should fail for\n%s\ngot %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// options are the flags shared by the build, watch, routes and check commands.
+type options struct {
+	targets  []target
+	noClient bool
+	tinygo   bool
+	budgets  budgets
+	flags    buildFlags
+}
+
+// target is one package to build and the directory it is generated into.
+type target struct {
+	pkg       string
+	outDir    string
+	clientPkg string // empty: the wasm-tagged files of pkg, skipped if absent
+}
+
+// buildFlags are passed through to go build and to the render subprocess.
+type buildFlags struct {
+	tags     string   // -tags
+	ldflags  string   // -ldflags, e.g. "-X main.version=1.2.0"
+	trimpath bool     // -trimpath
+	env      []string // KEY=VAL for the go tool and the render subprocess
+}
+
+// goArgs returns the go build flags, without the package.
+func (f buildFlags) goArgs() []string {
+	var args []string
+	if f.tags != "" {
+		args = append(args, "-tags", f.tags)
+	}
+	if f.ldflags != "" {
+		args = append(args, "-ldflags", f.ldflags)
+	}
+	if f.trimpath {
+		args = append(args, "-trimpath")
+	}
+	return args
+}
+
+// environ returns the process environment with f.env applied; extra entries
+// (e.g. GOOS=js) take precedence.
+func (f buildFlags) environ(extra ...string) []string {
+	env := append(os.Environ(), f.env...)
+	return append(env, extra...)
+}
+
+// fileConfig is the JSON file read with --config. Flags on the command line
+// override it; packages on the command line replace its package list.
+//
+//	{
+//	  "out": "dist",
+//	  "tags": "prod",
+//	  "ldflags": "-X main.version=1.2.0",
+//	  "trimpath": true,
+//	  "env": {"APP_ENV": "production"},
+//	  "budget_bundle": "200KB",
+//	  "budget_wasm": "2MB",
+//	  "packages": [{"path": "./cmd/site"}, {"path": "./cmd/docs", "out": "dist/docs"}]
+//	}
+type fileConfig struct {
+	Out          string            `json:"out"`
+	Client       string            `json:"client"`
+	NoClient     bool              `json:"no_client"`
+	TinyGo       bool              `json:"tinygo"`
+	Tags         string            `json:"tags"`
+	LDFlags      string            `json:"ldflags"`
+	TrimPath     bool              `json:"trimpath"`
+	Env          map[string]string `json:"env"`
+	BudgetModule string            `json:"budget_module"`
+	BudgetBundle string            `json:"budget_bundle"`
+	BudgetWasm   string            `json:"budget_wasm"`
+	Packages     []struct {
+		Path   string `json:"path"`
+		Out    string `json:"out"`
+		Client string `json:"client"`
+	} `json:"packages"`
+}
+
+var errUsage = errors.New("usage")
+
+// parseArgs parses [--config <file>] [--out <dir>] [--client <pkg> | --no-client]
+// [--tinygo] [--tags <tags>] [--ldflags <flags>] [--trimpath] [--env KEY=VAL]...
+// [--budget-* <size>] <package-path>...
+//
+// With several packages each one is generated into <out>/<package base name>.
+func parseArgs(args []string) (*options, error) {
+	o := &options{}
+	out := ""
+	client := ""
+	var pkgs []target
+
+	// The config file is applied first so flags override it wherever they appear
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--config" {
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, errors.New("--config requires a file argument")
+		}
+		cfg, err := readConfig(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		out, client = cfg.Out, cfg.Client
+		o.noClient, o.tinygo = cfg.NoClient, cfg.TinyGo
+		o.flags = buildFlags{tags: cfg.Tags, ldflags: cfg.LDFlags, trimpath: cfg.TrimPath}
+		keys := make([]string, 0, len(cfg.Env))
+		for k := range cfg.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.flags.env = append(o.flags.env, k+"="+cfg.Env[k])
+		}
+		for _, sizes := range []struct {
+			value string
+			dst   *int64
+		}{{cfg.BudgetModule, &o.budgets.module}, {cfg.BudgetBundle, &o.budgets.bundle}, {cfg.BudgetWasm, &o.budgets.wasm}} {
+			if sizes.value == "" {
+				continue
+			}
+			size, err := parseSize(sizes.value)
+			if err != nil {
+				return nil, errors.New(args[i+1] + ": " + err.Error())
+			}
+			*sizes.dst = size
+		}
+		for _, p := range cfg.Packages {
+			if p.Path == "" {
+				return nil, errors.New(args[i+1] + ": package without path")
+			}
+			pkgs = append(pkgs, target{pkg: p.Path, outDir: p.Out, clientPkg: p.Client})
+		}
+		break
+	}
+
+	var cliPkgs []target
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// value returns the argument of a flag that takes one
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", errors.New(arg + " requires an argument")
+			}
+			i++
+			return args[i], nil
+		}
+		var err error
+		switch arg {
+		case "--config":
+			i++ // applied above
+		case "--out", "-out", "-o":
+			out, err = value()
+		case "--client":
+			client, err = value()
+		case "--no-client":
+			o.noClient = true
+		case "--tinygo":
+			o.tinygo = true
+		case "--tags":
+			o.flags.tags, err = value()
+		case "--ldflags":
+			o.flags.ldflags, err = value()
+		case "--trimpath":
+			o.flags.trimpath = true
+		case "--env":
+			var kv string
+			if kv, err = value(); err == nil {
+				if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+					err = errors.New("--env expects KEY=VAL, got " + kv)
+				} else {
+					o.flags.env = append(o.flags.env, kv)
+				}
+			}
+		case "--budget-module", "--budget-bundle", "--budget-wasm":
+			var v string
+			if v, err = value(); err == nil {
+				var size int64
+				if size, err = parseSize(v); err != nil {
+					err = errors.New(arg + ": " + err.Error())
+				} else if arg == "--budget-module" {
+					o.budgets.module = size
+				} else if arg == "--budget-bundle" {
+					o.budgets.bundle = size
+				} else {
+					o.budgets.wasm = size
+				}
+			}
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return nil, errors.New("unknown flag: " + arg)
+			}
+			if strings.Contains(arg, "...") {
+				return nil, errors.New("package patterns are not supported: " + arg)
+			}
+			cliPkgs = append(cliPkgs, target{pkg: arg})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(cliPkgs) > 0 {
+		pkgs = cliPkgs
+	}
+	if len(pkgs) == 0 {
+		return nil, errUsage
+	}
+
+	if out == "" {
+		out = "dist"
+	}
+	if client != "" && len(pkgs) > 1 {
+		return nil, errors.New("--client needs a single package; set \"client\" per package in --config")
+	}
+	seen := make(map[string]string)
+	for i := range pkgs {
+		t := &pkgs[i]
+		if t.outDir == "" {
+			t.outDir = out
+			if len(pkgs) > 1 {
+				t.outDir = filepath.Join(out, packageBase(t.pkg))
+			}
+		}
+		if t.clientPkg == "" {
+			t.clientPkg = client
+		}
+		if prev, ok := seen[filepath.Clean(t.outDir)]; ok {
+			return nil, errors.New(prev + " and " + t.pkg + " both generate into " + t.outDir)
+		}
+		seen[filepath.Clean(t.outDir)] = t.pkg
+	}
+	o.targets = pkgs
+
+	if o.tinygo {
+		if _, err := exec.LookPath("tinygo"); err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild: tinygo not found in PATH, using go")
+			o.tinygo = false
+		}
+	}
+	return o, nil
+}
+
+// readConfig reads a --config file, rejecting unknown keys.
+func readConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg fileConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return &cfg, nil
+}
+
+// packageBase names the output subdirectory of a package path.
+func packageBase(pkg string) string {
+	base := filepath.Base(filepath.Clean(pkg))
+	if base == "." || base == string(filepath.Separator) {
+		if wd, err := os.Getwd(); err == nil {
+			base = filepath.Base(wd)
+		}
+	}
+	return base
+}
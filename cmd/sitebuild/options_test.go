@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsRejectsUnknownFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--outdir", "dist", "./app"},
+		{"-v", "./app"},
+		{"--env", "NOVALUE", "./app"},
+		{"./..."},
+		{"--tags"},
+	} {
+		if _, err := parseArgs(args); err == nil {
+			t.Errorf("parseArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestParseArgsMultiplePackages(t *testing.T) {
+	o, err := parseArgs([]string{"--out", "dist", "./cmd/site", "./cmd/docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dirs []string
+	for _, tg := range o.targets {
+		dirs = append(dirs, tg.outDir)
+	}
+	want := []string{filepath.Join("dist", "site"), filepath.Join("dist", "docs")}
+	if !slices.Equal(dirs, want) {
+		t.Errorf("output dirs = %v, want %v", dirs, want)
+	}
+
+	if _, err := parseArgs([]string{"./a/site", "./b/site"}); err == nil {
+		t.Error("two packages generating into the same dir should fail")
+	}
+	if _, err := parseArgs([]string{"--client", "./web", "./a", "./b"}); err == nil {
+		t.Error("--client with several packages should fail")
+	}
+}
+
+func TestParseArgsConfigFile(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "sitebuild.json")
+	os.WriteFile(cfg, []byte(`{
+		"out": "public",
+		"tags": "prod",
+		"ldflags": "-X main.version=1.2.0",
+		"trimpath": true,
+		"env": {"B": "2", "A": "1"},
+		"budget_bundle": "200KB",
+		"budget_wasm": "2MB",
+		"packages": [{"path": "./cmd/site"}, {"path": "./cmd/docs", "out": "docs-out"}]
+	}`), 0644)
+
+	o, err := parseArgs([]string{"--config", cfg, "--tags", "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.flags.tags != "staging" {
+		t.Errorf("tags = %q, the flag should override the file", o.flags.tags)
+	}
+	if o.flags.ldflags != "-X main.version=1.2.0" || !o.flags.trimpath {
+		t.Errorf("flags = %+v, want ldflags and trimpath from the file", o.flags)
+	}
+	if !slices.Equal(o.flags.env, []string{"A=1", "B=2"}) {
+		t.Errorf("env = %v, want [A=1 B=2]", o.flags.env)
+	}
+	if o.budgets.bundle != 200<<10 || o.budgets.wasm != 2<<20 {
+		t.Errorf("budgets = %+v", o.budgets)
+	}
+	if len(o.targets) != 2 || o.targets[0].outDir != filepath.Join("public", "site") || o.targets[1].outDir != "docs-out" {
+		t.Errorf("targets = %+v", o.targets)
+	}
+
+	// Packages on the command line replace the file's list
+	o, err = parseArgs([]string{"--config", cfg, "./app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.targets) != 1 || o.targets[0].pkg != "./app" || o.targets[0].outDir != "public" {
+		t.Errorf("targets = %+v, want ./app into public", o.targets)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte(`{"tag": "prod"}`), 0644)
+	if _, err := parseArgs([]string{"--config", bad, "./app"}); err == nil || !strings.Contains(err.Error(), "tag") {
+		t.Errorf("unknown config key: err = %v", err)
+	}
+}
+
+func TestBuildFlagsGoArgs(t *testing.T) {
+	f := buildFlags{tags: "prod", ldflags: "-s -w", trimpath: true}
+	want := []string{"-tags", "prod", "-ldflags", "-s -w", "-trimpath"}
+	if got := f.goArgs(); !slices.Equal(got, want) {
+		t.Errorf("goArgs() = %q, want %q", got, want)
+	}
+	if got := (buildFlags{}).goArgs(); len(got) != 0 {
+		t.Errorf("empty flags: goArgs() = %q", got)
+	}
+}
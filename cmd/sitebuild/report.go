@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// buildReport mirrors the JSON written by site.AutoBuild with --ssr-report.
+type buildReport struct {
+	OutputDir  string         `json:"output_dir"`
+	BasePath   string         `json:"base_path"`
+	DurationMS float64        `json:"duration_ms"`
+	CSSBytes   int            `json:"css_bytes"`
+	JSBytes    int            `json:"js_bytes"`
+	Modules    []moduleReport `json:"modules"`
+	Files      []fileReport   `json:"files"`
+	Failures   []string       `json:"failures"`
+}
+
+type moduleReport struct {
+	Name       string   `json:"name"`
+	Mode       string   `json:"mode"`
+	HTMLBytes  int      `json:"html_bytes"`
+	CSSBytes   int      `json:"css_bytes"`
+	JSBytes    int      `json:"js_bytes"`
+	Icons      []string `json:"icons"`
+	DurationMS float64  `json:"duration_ms"`
+}
+
+type fileReport struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+func readReport(path string) (*buildReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r buildReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// printSummary writes one line per module and per output file.
+func printSummary(w io.Writer, r *buildReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tMODE\tHTML\tCSS\tJS\tICONS\tTIME")
+	for _, m := range r.Modules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%.2fms\n", m.Name, m.Mode,
+			formatSize(int64(m.HTMLBytes)), formatSize(int64(m.CSSBytes)), formatSize(int64(m.JSBytes)),
+			len(m.Icons), m.DurationMS)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "FILE\tSIZE")
+	for _, f := range r.Files {
+		fmt.Fprintf(tw, "%s\t%s\n", f.Path, formatSize(f.Bytes))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "ssrBuild: %.2fms\n", r.DurationMS)
+}
+
+// wasmName is the wasm client file in the output dir.
+const wasmName = "client.wasm"
+
+// budgets are size limits in bytes; zero disables a limit.
+type budgets struct {
+	module int64 // HTML + CSS + JS contributed by one module
+	bundle int64 // any single output file (client.wasm and precompressed siblings excluded)
+	wasm   int64 // client.wasm
+}
+
+// check returns one message per exceeded budget.
+func (b budgets) check(r *buildReport) []string {
+	var out []string
+	if b.module > 0 {
+		for _, m := range r.Modules {
+			if total := int64(m.HTMLBytes + m.CSSBytes + m.JSBytes); total > b.module {
+				out = append(out, fmt.Sprintf("module %s is %s (budget %s)", m.Name, formatSize(total), formatSize(b.module)))
+			}
+		}
+	}
+	if b.bundle > 0 {
+		for _, f := range r.Files {
+			if f.Path == wasmName || strings.HasSuffix(f.Path, ".gz") || strings.HasSuffix(f.Path, ".br") {
+				continue
+			}
+			if f.Bytes > b.bundle {
+				out = append(out, fmt.Sprintf("file %s is %s (budget %s)", f.Path, formatSize(f.Bytes), formatSize(b.bundle)))
+			}
+		}
+	}
+	if b.wasm > 0 {
+		for _, f := range r.Files {
+			if f.Path == wasmName && f.Bytes > b.wasm {
+				out = append(out, fmt.Sprintf("file %s is %s (budget %s)", f.Path, formatSize(f.Bytes), formatSize(b.wasm)))
+			}
+		}
+	}
+	return out
+}
+
+// parseSize parses sizes such as "512", "20KB" or "1.5MB" (1KB = 1024 bytes).
+func parseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	mult := 1.0
+	for _, u := range []struct {
+		suffix string
+		mult   float64
+	}{{"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSuffix(v, u.suffix)
+			mult = u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid size " + strconv.Quote(s))
+	}
+	return int64(n * mult), nil
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// routeInfo mirrors site.RouteInfo as written by AutoBuild with --ssr-routes.
+type routeInfo struct {
+	Name     string            `json:"name"`
+	Title    string            `json:"title"`
+	Mode     string            `json:"mode"`
+	Verbs    []string          `json:"verbs"`
+	Roles    map[string]string `json:"roles"`
+	CSSBytes int               `json:"css_bytes"`
+	JSBytes  int               `json:"js_bytes"`
+	Icons    []string          `json:"icons"`
+}
+
+// runRoutes implements `sitebuild routes [--json] [build flags] <package-path>`.
+func runRoutes(b builder, args []string, stdout io.Writer) int {
+	asJSON := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--json" {
+			asJSON = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	o, err := parseArgs(rest)
+	if errors.Is(err, errUsage) {
+		printUsage()
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
+		return 1
+	}
+	if len(o.targets) != 1 {
+		fmt.Fprintln(os.Stderr, "sitebuild: routes takes a single package")
+		return 1
+	}
+	pkg := o.targets[0].pkg
+
+	tmpDir, err := os.MkdirTemp("", "sitebuild-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: failed to create temp dir:", err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bin := filepath.Join(tmpDir, "sitebuild_app")
+	if err := b.Build(pkg, bin, o.flags); err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: build failed:", err)
+		return 1
+	}
+	routesFile := filepath.Join(tmpDir, "routes.json")
+	if err := b.Run(bin, []string{"--ssr-routes", routesFile}, o.flags.env); err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: listing routes failed:", err)
+		return 1
+	}
+	data, err := os.ReadFile(routesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: no routes written (site.AutoBuild too old?):", err)
+		return 1
+	}
+
+	if asJSON {
+		stdout.Write(data)
+		return 0
+	}
+	var routes []routeInfo
+	if err := json.Unmarshal(data, &routes); err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: invalid routes JSON:", err)
+		return 1
+	}
+	printRoutes(stdout, routes)
+	return 0
+}
+
+// printRoutes writes one line per registered handler.
+func printRoutes(w io.Writer, routes []routeInfo) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTITLE\tMODE\tVERBS\tROLES\tCSS\tJS\tICONS")
+	for _, r := range routes {
+		verbs := strings.Join(r.Verbs, ",")
+		if verbs == "" {
+			verbs = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", r.Name, dash(r.Title), r.Mode, verbs, formatRoles(r.Roles),
+			formatSize(int64(r.CSSBytes)), formatSize(int64(r.JSBytes)), len(r.Icons))
+	}
+	tw.Flush()
+}
+
+// formatRoles renders roles as "create=ae read=*" in CRUD order.
+func formatRoles(roles map[string]string) string {
+	var parts []string
+	for _, verb := range []string{"create", "read", "update", "delete"} {
+		if codes, ok := roles[verb]; ok {
+			parts = append(parts, verb+"="+codes)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testRoutes = `[
+ {"name":"home","title":"Home Page","mode":"ssr","verbs":[],"roles":{"read":"*"},"css_bytes":2048,"js_bytes":0,"icons":["logo"]},
+ {"name":"tickets","mode":"api","verbs":["create","delete"],"roles":{"delete":"a","create":"ae"},"css_bytes":0,"js_bytes":0}
+]`
+
+func TestRunRoutesTable(t *testing.T) {
+	var out bytes.Buffer
+	fb := &fakeBuilder{routes: testRoutes}
+	if code := runRoutes(fb, []string{"./app"}, &out); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got:\n%s", out.String())
+	}
+	for _, want := range []string{"home", "Home Page", "ssr", "read=*", "2.0KB"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("home row missing %q: %s", want, lines[1])
+		}
+	}
+	for _, want := range []string{"tickets", "api", "create,delete", "create=ae delete=a"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("tickets row missing %q: %s", want, lines[2])
+		}
+	}
+	if fb.runArgs[0] != "--ssr-routes" {
+		t.Errorf("run args = %v", fb.runArgs)
+	}
+}
+
+func TestRunRoutesJSON(t *testing.T) {
+	var out bytes.Buffer
+	if code := runRoutes(&fakeBuilder{routes: testRoutes}, []string{"--json", "./app"}, &out); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if out.String() != testRoutes {
+		t.Errorf("JSON output = %q, want the routes file unchanged", out.String())
+	}
+}
+
+func TestRunRoutesUsage(t *testing.T) {
+	if code := runRoutes(&fakeBuilder{}, nil, &bytes.Buffer{}); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinywasm/site/internal/httpenc"
+)
+
+// runServe implements `sitebuild serve [--addr <addr>] [<dir>]`.
+func runServe(args []string) int {
+	addr := "localhost:8080"
+	dir := "dist"
+	dirSet := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--addr", "-addr":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "sitebuild: --addr requires an address argument")
+				return 1
+			}
+			i++
+			addr = args[i]
+		default:
+			if dirSet {
+				fmt.Fprintln(os.Stderr, "sitebuild: unexpected argument:", args[i])
+				return 1
+			}
+			dir, dirSet = args[i], true
+		}
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintln(os.Stderr, "sitebuild: no static output in", dir, "(run sitebuild --out", dir, "<package-path> first)")
+		return 1
+	}
+
+	fmt.Println("sitebuild: serving", dir, "on http://"+addr)
+	if err := http.ListenAndServe(addr, newStaticHandler(dir)); err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: serve:", err)
+		return 1
+	}
+	return 0
+}
+
+// staticHandler serves a BuildStatic output directory the way a static host
+// would: correct media types (application/wasm), .br/.gz siblings negotiated
+// from Accept-Encoding, index.html for directories and extensionless paths
+// (path-mode routes such as /users/42) and 404.html for missing files.
+type staticHandler struct {
+	dir string
+}
+
+func newStaticHandler(dir string) http.Handler {
+	return &staticHandler{dir: dir}
+}
+
+func (s *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(name, "/") {
+		name += "index.html"
+	} else if s.isDir(name) {
+		name += "/index.html"
+	}
+
+	switch {
+	case s.isFile(name):
+		s.serveFile(w, r, name, http.StatusOK)
+	case path.Ext(name) == "" && s.isFile("/index.html"):
+		// SPA fallback, as static hosts' rewrite rules do; the client routes
+		// on the #hash only, so the page opens on the default route
+		s.serveFile(w, r, "/index.html", http.StatusOK)
+	case s.isFile("/404.html"):
+		s.serveFile(w, r, "/404.html", http.StatusNotFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *staticHandler) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+func (s *staticHandler) isFile(name string) bool {
+	info, err := os.Stat(s.path(name))
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (s *staticHandler) isDir(name string) bool {
+	info, err := os.Stat(s.path(name))
+	return err == nil && info.IsDir()
+}
+
+// serveFile writes name, or its best precompressed sibling, with status.
+func (s *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, status int) {
+	h := w.Header()
+	h.Set("Content-Type", contentType(name))
+
+	file := s.path(name)
+	br, gz := s.isFile(name+".br"), s.isFile(name+".gz")
+	if br || gz {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	accept := r.Header.Get("Accept-Encoding")
+	switch {
+	case br && httpenc.AcceptsEncoding(accept, "br"):
+		h.Set("Content-Encoding", "br")
+		file += ".br"
+	case gz && httpenc.AcceptsEncoding(accept, "gzip"):
+		h.Set("Content-Encoding", "gzip")
+		file += ".gz"
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if status != http.StatusOK {
+		// ServeContent only writes 200/206/304; error pages keep their status
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			http.ServeContent(discardHeaders{w}, r, name, info.ModTime(), f)
+		}
+		return
+	}
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// discardHeaders lets ServeContent copy a body after the status was written.
+type discardHeaders struct{ http.ResponseWriter }
+
+func (discardHeaders) WriteHeader(int) {}
+
+func contentType(name string) string {
+	switch path.Ext(name) {
+	case ".wasm":
+		return "application/wasm"
+	case ".html":
+		return "text/html; charset=utf-8"
+	case ".js":
+		return "text/javascript; charset=utf-8"
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDist(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func serve(h http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticHandler(t *testing.T) {
+	h := newStaticHandler(writeDist(t, map[string]string{
+		"index.html":      "<html>index</html>",
+		"404.html":        "<html>missing</html>",
+		"client.wasm":     "\x00asm",
+		"script.js":       "plain",
+		"script.js.gz":    "gzipped",
+		"script.js.br":    "brotli",
+		"docs/index.html": "<html>docs</html>",
+	}))
+
+	tests := []struct {
+		path, accept string
+		status       int
+		body, ctype  string
+		encoding     string
+	}{
+		{"/", "", 200, "<html>index</html>", "text/html; charset=utf-8", ""},
+		{"/client.wasm", "", 200, "\x00asm", "application/wasm", ""},
+		{"/script.js", "", 200, "plain", "text/javascript; charset=utf-8", ""},
+		{"/script.js", "gzip", 200, "gzipped", "text/javascript; charset=utf-8", "gzip"},
+		{"/script.js", "gzip, br", 200, "brotli", "text/javascript; charset=utf-8", "br"},
+		{"/script.js", "br;q=0, gzip", 200, "gzipped", "text/javascript; charset=utf-8", "gzip"},
+		{"/docs", "", 200, "<html>docs</html>", "text/html; charset=utf-8", ""},
+		{"/users/42", "", 200, "<html>index</html>", "text/html; charset=utf-8", ""},
+		{"/missing.css", "", 404, "<html>missing</html>", "text/html; charset=utf-8", ""},
+		{"/../secret.txt", "", 404, "<html>missing</html>", "text/html; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(h, tt.path, tt.accept)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s (%q): got %d %q, want %d %q", tt.path, tt.accept, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != tt.ctype {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.path, ct, tt.ctype)
+		}
+		if enc := rec.Header().Get("Content-Encoding"); enc != tt.encoding {
+			t.Errorf("%s (%q): Content-Encoding = %q, want %q", tt.path, tt.accept, enc, tt.encoding)
+		}
+	}
+
+	if vary := serve(h, "/script.js", "").Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", vary)
+	}
+}
+
+func TestStaticHandlerWithout404Page(t *testing.T) {
+	h := newStaticHandler(writeDist(t, map[string]string{"style.css": "a{}"}))
+	if rec := serve(h, "/nope.js", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	// No index.html: extensionless paths are not found either
+	if rec := serve(h, "/users/42", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	reloadPath   = "/__sitebuild/reload"
+	reloadScript = reloadPath + ".js"
+	// reloadJS is served as an external script so pages with a strict
+	// Content-Security-Policy (script-src 'self') still reload.
+	reloadJS = `new EventSource("` + reloadPath + `").onmessage = function () { location.reload(); };` + "\n"
+)
+
+// generatedFiles are rewritten by every watch rebuild. assetmin never
+// overwrites existing output files, so they are removed before rerunning.
+var generatedFiles = []string{"index.html", "style.css", "script.js", "icons.svg", "sitemap.xml", "robots.txt"}
+
+// runWatch implements `sitebuild watch [--addr <addr>] [build flags] <package-path>`.
+func runWatch(b builder, args []string) int {
+	addr := "localhost:8080"
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--addr" || args[i] == "-addr" {
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "sitebuild: --addr requires an address argument")
+				return 1
+			}
+			i++
+			addr = args[i]
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	o, err := parseArgs(rest)
+	if errors.Is(err, errUsage) {
+		printUsage()
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
+		return 1
+	}
+	if len(o.targets) != 1 {
+		fmt.Fprintln(os.Stderr, "sitebuild: watch takes a single package")
+		return 1
+	}
+	t := o.targets[0]
+
+	tmpDir, err := os.MkdirTemp("", "sitebuild-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sitebuild: failed to create temp dir:", err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	w := newWatcher(newProject(b, o, 0, tmpDir))
+	if err := w.rebuild(true, true); err != nil {
+		// Keep watching: the next save may fix it
+		fmt.Fprintln(os.Stderr, "sitebuild:", err)
+	}
+
+	hub := newReloadHub()
+	go func() {
+		fmt.Println("sitebuild: watching", t.pkg, "— serving", t.outDir, "on http://"+addr)
+		if err := http.ListenAndServe(addr, newWatchHandler(t.outDir, hub)); err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild: serve:", err)
+			os.Exit(1)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	tick := time.NewTicker(300 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stop:
+			return 0
+		case <-tick.C:
+			server, client := w.changes()
+			if !server && !client {
+				continue
+			}
+			if err := w.rebuild(server, client); err != nil {
+				fmt.Fprintln(os.Stderr, "sitebuild:", err)
+				continue
+			}
+			hub.broadcast()
+		}
+	}
+}
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// watcher polls the directories of the local packages the server and the
+// wasm client depend on. A change in a server dependency recompiles the
+// server and reruns the static build; a change that only the client depends
+// on recompiles client.wasm alone.
+type watcher struct {
+	p      *project
+	server map[string]bool // package dirs of the server build
+	client map[string]bool // package dirs of the client build
+	snap   map[string]fileStamp
+}
+
+func newWatcher(p *project) *watcher {
+	return &watcher{p: p, server: map[string]bool{}, client: map[string]bool{}, snap: map[string]fileStamp{}}
+}
+
+// refreshDeps re-resolves the watched directories; imports may have changed.
+// It leaves the snapshot to the caller.
+func (w *watcher) refreshDeps() error {
+	server, err := w.p.b.Deps(w.p.t.pkg, false, w.p.o.flags)
+	if err != nil {
+		return errors.New("listing dependencies: " + err.Error())
+	}
+	w.server = toSet(server)
+	w.client = map[string]bool{}
+	if !w.p.o.noClient {
+		pkg, _ := w.p.client()
+		client, err := w.p.b.Deps(pkg, true, w.p.o.flags)
+		if err != nil {
+			return errors.New("listing client dependencies: " + err.Error())
+		}
+		w.client = toSet(client)
+	}
+	return nil
+}
+
+func toSet(dirs []string) map[string]bool {
+	set := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		if abs, err := filepath.Abs(d); err == nil {
+			set[abs] = true
+		}
+	}
+	return set
+}
+
+// scan stamps every regular file directly inside a watched directory
+// (sources and embedded module assets), skipping the output directory.
+func (w *watcher) scan() map[string]fileStamp {
+	out, _ := filepath.Abs(w.p.t.outDir)
+	snap := make(map[string]fileStamp)
+	for _, set := range []map[string]bool{w.server, w.client} {
+		for dir := range set {
+			if dir == out || strings.HasPrefix(dir, out+string(filepath.Separator)) {
+				continue
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				info, err := e.Info()
+				if err != nil || !info.Mode().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+					continue
+				}
+				snap[filepath.Join(dir, e.Name())] = fileStamp{mod: info.ModTime(), size: info.Size()}
+			}
+		}
+	}
+	return snap
+}
+
+// settle scans the watched files after a build that started at start,
+// when before was taken. Files saved during the build are left out of the
+// snapshot, and files removed meanwhile keep their old stamp, so the next
+// changes call reports them.
+func (w *watcher) settle(before map[string]fileStamp, start time.Time) map[string]fileStamp {
+	after := w.scan()
+	snap := make(map[string]fileStamp, len(after))
+	for path, st := range after {
+		old, seen := before[path]
+		if (seen && old != st) || (!seen && !st.mod.Before(start)) {
+			continue
+		}
+		snap[path] = st
+	}
+	for path, st := range before {
+		if _, ok := after[path]; !ok {
+			snap[path] = st
+		}
+	}
+	return snap
+}
+
+// changes reports which builds are affected by files added, removed or
+// modified since the last scan.
+func (w *watcher) changes() (server, client bool) {
+	next := w.scan()
+	mark := func(path string) {
+		dir := filepath.Dir(path)
+		server = server || w.server[dir]
+		client = client || w.client[dir]
+	}
+	for path, st := range next {
+		if old, ok := w.snap[path]; !ok || old != st {
+			mark(path)
+		}
+	}
+	for path := range w.snap {
+		if _, ok := next[path]; !ok {
+			mark(path)
+		}
+	}
+	w.snap = next
+	return server, client
+}
+
+// rebuild runs the affected build steps and refreshes the watched set.
+func (w *watcher) rebuild(server, client bool) error {
+	start := time.Now()
+	before := w.scan()
+	defer func() {
+		// Watch whatever the (possibly failed) build depends on now
+		if err := w.refreshDeps(); err != nil {
+			fmt.Fprintln(os.Stderr, "sitebuild:", err)
+		}
+		w.snap = w.settle(before, start)
+	}()
+	if client {
+		hadClient := w.p.withClient
+		if err := w.p.buildClient(); err != nil {
+			return err
+		}
+		// script.js only embeds the loader when client.wasm exists
+		server = server || hadClient != w.p.withClient
+	}
+	if server {
+		if err := w.p.buildServer(); err != nil {
+			return err
+		}
+		for _, name := range generatedFiles {
+			for _, ext := range []string{"", ".gz", ".br"} {
+				os.Remove(filepath.Join(w.p.t.outDir, name+ext))
+			}
+		}
+		rep, err := w.p.generate()
+		if err != nil {
+			return err
+		}
+		if rep != nil {
+			for _, v := range w.p.o.budgets.check(rep) {
+				fmt.Fprintln(os.Stderr, "sitebuild: budget exceeded:", v)
+			}
+		}
+	}
+	fmt.Println("sitebuild: rebuilt in", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// reloadHub fans reload events out to connected browsers over SSE.
+type reloadHub struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newReloadHub() *reloadHub {
+	return &reloadHub{clients: make(map[chan struct{}]bool)}
+}
+
+func (h *reloadHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// broadcast asks every connected browser to reload.
+func (h *reloadHub) broadcast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- struct{}{}:
+		default: // a reload is already pending
+		}
+	}
+}
+
+func (h *reloadHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.clients[ch] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, ch)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			if _, err := w.Write([]byte("data: reload\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// newWatchHandler serves dir like `sitebuild serve` and adds the reload
+// endpoint and script to every HTML page.
+func newWatchHandler(dir string, hub *reloadHub) http.Handler {
+	static := newStaticHandler(dir)
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, hub)
+	mux.HandleFunc(reloadScript, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Write([]byte(reloadJS))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Always serve fresh, uncompressed files so the script can be inserted
+		r = r.Clone(r.Context())
+		for _, h := range []string{"Accept-Encoding", "If-Modified-Since", "If-None-Match", "Range"} {
+			r.Header.Del(h)
+		}
+		rec := &capturedResponse{header: w.Header(), status: http.StatusOK}
+		static.ServeHTTP(rec, r)
+
+		body := rec.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			body = injectReload(body)
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(rec.status)
+		w.Write(body)
+	})
+	return mux
+}
+
+// injectReload adds the reload script before </body>, or at the end.
+func injectReload(page []byte) []byte {
+	tag := []byte(`<script src="` + reloadScript + `"></script>`)
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(page, tag...)
+	}
+	out := make([]byte, 0, len(page)+len(tag))
+	out = append(out, page[:i]...)
+	out = append(out, tag...)
+	return append(out, page[i:]...)
+}
+
+// capturedResponse buffers a body while sharing the real header map.
+type capturedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturedResponse) Header() http.Header         { return c.header }
+func (c *capturedResponse) Write(p []byte) (int, error) { return c.body.Write(p) }
+func (c *capturedResponse) WriteHeader(status int)      { c.status = status }
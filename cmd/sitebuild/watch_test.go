@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// touch writes name with a modification time distinct from earlier writes.
+func touch(t *testing.T, name, body string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Now().Add(age)
+	if err := os.Chtimes(name, ts, ts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherRebuildsAffectedSteps(t *testing.T) {
+	root := t.TempDir()
+	shared := filepath.Join(root, "modules")
+	server := filepath.Join(root, "app")
+	out := filepath.Join(root, "dist")
+	for _, d := range []string{shared, server} {
+		os.MkdirAll(d, 0755)
+	}
+	touch(t, filepath.Join(shared, "home.go"), "package modules", -time.Hour)
+	touch(t, filepath.Join(server, "server.go"), "package main", -time.Hour)
+
+	fb := &fakeBuilder{
+		report:     testReport,
+		client:     true,
+		serverDeps: []string{shared, server},
+		clientDeps: []string{shared},
+	}
+	o := &options{targets: []target{{pkg: "./app", outDir: out}}}
+	w := newWatcher(newProject(fb, o, 0, t.TempDir()))
+	if err := w.rebuild(true, true); err != nil {
+		t.Fatalf("initial build: %v", err)
+	}
+	if fb.builds != 1 || fb.wasmBuilds != 1 || fb.runs != 1 {
+		t.Fatalf("initial build: builds=%d wasm=%d runs=%d", fb.builds, fb.wasmBuilds, fb.runs)
+	}
+
+	if s, c := w.changes(); s || c {
+		t.Fatalf("no edits, got server=%v client=%v", s, c)
+	}
+
+	// A save while the server compiles is picked up by the next poll
+	fb.onBuild = func() { touch(t, filepath.Join(server, "server.go"), "package main // mid-build", 0) }
+	if err := w.rebuild(true, false); err != nil {
+		t.Fatal(err)
+	}
+	fb.onBuild = nil
+	if s, c := w.changes(); !s || c {
+		t.Fatalf("edit during the build, got server=%v client=%v", s, c)
+	}
+
+	// Output files never trigger a rebuild
+	touch(t, filepath.Join(out, "index.html"), "<html></html>", 0)
+	if s, c := w.changes(); s || c {
+		t.Fatalf("output edit, got server=%v client=%v", s, c)
+	}
+
+	// A server-only source reruns the static build without the client
+	touch(t, filepath.Join(server, "server.go"), "package main // edited", 0)
+	s, c := w.changes()
+	if !s || c {
+		t.Fatalf("server edit, got server=%v client=%v", s, c)
+	}
+	if err := w.rebuild(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if fb.builds != 3 || fb.wasmBuilds != 1 || fb.runs != 3 {
+		t.Fatalf("server edit: builds=%d wasm=%d runs=%d", fb.builds, fb.wasmBuilds, fb.runs)
+	}
+
+	// A module asset used by both rebuilds both; a new file counts as a change
+	touch(t, filepath.Join(shared, "home.css"), "h1{}", time.Minute)
+	s, c = w.changes()
+	if !s || !c {
+		t.Fatalf("shared edit, got server=%v client=%v", s, c)
+	}
+
+	// Removing a client-only dependency file recompiles client.wasm alone
+	fb.serverDeps = []string{server}
+	if err := w.refreshDeps(); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(shared, "home.css"))
+	s, c = w.changes()
+	if s || !c {
+		t.Fatalf("client edit, got server=%v client=%v", s, c)
+	}
+	if err := w.rebuild(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if fb.builds != 3 || fb.wasmBuilds != 2 || fb.runs != 3 {
+		t.Fatalf("client edit: builds=%d wasm=%d runs=%d", fb.builds, fb.wasmBuilds, fb.runs)
+	}
+}
+
+func TestWatchHandlerReload(t *testing.T) {
+	dir := writeDist(t, map[string]string{
+		"index.html":    "<html><body><h1>Hi</h1></body></html>",
+		"index.html.gz": "gzipped",
+		"style.css":     "a{}",
+	})
+	hub := newReloadHub()
+	srv := httptest.NewServer(newWatchHandler(dir, hub))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	want := `<h1>Hi</h1><script src="/__sitebuild/reload.js"></script></body>`
+	if !strings.Contains(string(page), want) {
+		t.Errorf("page missing reload script:\n%s", page)
+	}
+
+	res, err = http.Get(srv.URL + "/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	css, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(css) != "a{}" {
+		t.Errorf("style.css = %q, want untouched", css)
+	}
+
+	res, err = http.Get(srv.URL + reloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	for deadline := time.Now().Add(2 * time.Second); hub.count() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("SSE client never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	hub.broadcast()
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil || line != "data: reload\n" {
+		t.Fatalf("event = %q, %v", line, err)
+	}
+}
@@ -0,0 +1,247 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tinywasm/site/internal/httpenc"
+)
+
+// isCompressible reports whether a media type benefits from compression.
+func isCompressible(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "text/") ||
+		strings.Contains(ct, "javascript") ||
+		strings.Contains(ct, "json") ||
+		strings.Contains(ct, "xml") ||
+		strings.Contains(ct, "application/wasm")
+}
+
+// gzipBytes compresses data with the given gzip level.
+func gzipBytes(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// precompressDir writes a .gz sibling next to every compressible file in dir.
+// Files whose compressed form is not smaller are left without a sibling.
+func precompressDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext == ".gz" || ext == ".br" || !isCompressible(mime.TypeByExtension(ext)) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		gz, err := gzipBytes(data, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		if len(gz) >= len(data) {
+			return nil
+		}
+		return os.WriteFile(path+".gz", gz, 0644)
+	})
+}
+
+// bufferedResponse captures a handler response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+// compressedEntry is a captured asset with its precompressed variants.
+type compressedEntry struct {
+	header http.Header
+	etag   string // body hash; each variant's ETag adds its encoding
+	body   []byte
+	gz     []byte
+	br     []byte
+}
+
+// compressedAssets serves asset routes with precompressed variants negotiated
+// from Accept-Encoding. Outside DevMode each warmed route is compressed once
+// at the best level and reused; other paths (index fallbacks) are compressed
+// per request at the default level so the cache stays bounded. Every variant
+// carries an ETag and conditional requests get 304.
+type compressedAssets struct {
+	next    http.Handler
+	files   map[string]string // route -> file on disk that may have .br/.gz siblings
+	cache   bool
+	mu      sync.Mutex
+	entries map[string]*compressedEntry
+}
+
+func newCompressedAssets(next http.Handler, files map[string]string, cache bool) *compressedAssets {
+	return &compressedAssets{
+		next:    next,
+		files:   files,
+		cache:   cache,
+		entries: make(map[string]*compressedEntry),
+	}
+}
+
+// warm compresses the given routes ahead of the first request.
+func (c *compressedAssets) warm(paths ...string) {
+	if !c.cache {
+		return
+	}
+	for _, p := range paths {
+		req, err := http.NewRequest(http.MethodGet, p, nil)
+		if err != nil {
+			continue
+		}
+		c.store(p, c.capture(req, gzip.BestCompression))
+	}
+}
+
+// entry returns the response for the request path, or nil when it is not a
+// compressible 200.
+func (c *compressedAssets) entry(r *http.Request) *compressedEntry {
+	if c.cache {
+		c.mu.Lock()
+		e, ok := c.entries[r.URL.Path]
+		c.mu.Unlock()
+		if ok && e != nil {
+			return e
+		}
+	}
+	return c.capture(r, gzip.DefaultCompression)
+}
+
+// store caches e for a warmed route.
+func (c *compressedAssets) store(path string, e *compressedEntry) {
+	c.mu.Lock()
+	c.entries[path] = e
+	c.mu.Unlock()
+}
+
+// capture runs the request against next, without its conditional headers,
+// and compresses a 200 response at the given gzip level.
+func (c *compressedAssets) capture(r *http.Request, level int) *compressedEntry {
+	key := r.URL.Path
+	get := r.Clone(r.Context())
+	get.Method = http.MethodGet
+	get.Header.Del("Range")
+	get.Header.Del("If-Modified-Since")
+	get.Header.Del("If-None-Match")
+	rec := newBufferedResponse()
+	c.next.ServeHTTP(rec, get)
+
+	var e *compressedEntry
+	if rec.status == http.StatusOK && isCompressible(rec.header.Get("Content-Type")) {
+		sum := sha256.Sum256(rec.body.Bytes())
+		e = &compressedEntry{header: rec.header, etag: hex.EncodeToString(sum[:12]), body: rec.body.Bytes()}
+		e.header.Del("Content-Length")
+		if gz, err := gzipBytes(e.body, level); err == nil && len(gz) < len(e.body) {
+			e.gz = gz
+		}
+		if file, ok := c.files[key]; ok {
+			if br, err := os.ReadFile(file + ".br"); err == nil {
+				e.br = br
+			}
+			if gz, err := os.ReadFile(file + ".gz"); err == nil {
+				e.gz = gz
+			}
+		}
+	}
+	return e
+}
+
+func (c *compressedAssets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Range") != "" {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+	e := c.entry(r)
+	if e == nil {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = v
+	}
+	addVary(h, "Accept-Encoding")
+
+	body, etag := e.body, e.etag
+	accept := r.Header.Get("Accept-Encoding")
+	switch {
+	case e.br != nil && httpenc.AcceptsEncoding(accept, "br"):
+		h.Set("Content-Encoding", "br")
+		body, etag = e.br, etag+"-br"
+	case e.gz != nil && httpenc.AcceptsEncoding(accept, "gzip"):
+		h.Set("Content-Encoding", "gzip")
+		body, etag = e.gz, etag+"-gzip"
+	}
+	h.Set("ETag", `"`+etag+`"`)
+	if notModified(r, h) {
+		h.Del("Content-Type")
+		h.Del("Content-Encoding")
+		h.Del("Last-Modified")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(body)
+	}
+}
+
+// notModified reports whether the conditional headers of r match the ETag
+// and Last-Modified in h. If-None-Match takes precedence, as in RFC 9110.
+func notModified(r *http.Request, h http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(h.Get("ETag"), "W/")
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(h.Get("Last-Modified"))
+	return err == nil && !modified.After(since)
+}
@@ -0,0 +1,285 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tinywasm/fmt"
+)
+
+// ConfigFileEnv names the config file LoadConfig("") reads.
+const ConfigFileEnv = "SITE_CONFIG"
+
+// configKeys are the settings LoadConfig reads: key in the file, and
+// SITE_ plus the upper-cased key in the environment.
+var configKeys = []struct {
+	key string
+	set func(s *Site, v string) error
+}{
+	{"cache_size", intSetting((*Site).SetCacheSize)},
+	{"default_route", stringSetting((*Site).SetDefaultRoute)},
+	{"output_dir", stringSetting((*Site).SetOutputDir)},
+	{"dev_mode", boolSetting((*Site).SetDevMode)},
+	{"precompress", boolSetting((*Site).SetPrecompress)},
+	{"content_security_policy", stringSetting((*Site).SetContentSecurityPolicy)},
+	{"base_url", stringSetting((*Site).SetBaseURL)},
+	{"base_path", stringSetting((*Site).SetBasePath)},
+	{"robots_disallow", listSetting((*Site).SetRobotsDisallow)},
+	{"continue_on_render_error", boolSetting((*Site).SetContinueOnRenderError)},
+	{"link_check", boolSetting((*Site).SetLinkCheck)},
+	{"csrf", boolSetting((*Site).SetCSRF)},
+	{"health_endpoints", boolSetting((*Site).SetHealthEndpoints)},
+	{"metrics_path", stringSetting((*Site).SetMetricsPath)},
+}
+
+func intSetting(set func(*Site, int)) func(*Site, string) error {
+	return func(s *Site, v string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return errValue(v, "an integer")
+		}
+		set(s, n)
+		return nil
+	}
+}
+
+func boolSetting(set func(*Site, bool)) func(*Site, string) error {
+	return func(s *Site, v string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return errValue(v, "true or false")
+		}
+		set(s, b)
+		return nil
+	}
+}
+
+func stringSetting(set func(*Site, string)) func(*Site, string) error {
+	return func(s *Site, v string) error {
+		set(s, v)
+		return nil
+	}
+}
+
+// listSetting splits a comma-separated value; file arrays arrive joined.
+func listSetting(set func(*Site, ...string)) func(*Site, string) error {
+	return func(s *Site, v string) error {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		set(s, items...)
+		return nil
+	}
+}
+
+func errValue(v, want string) error {
+	return fmt.Err(strconv.Quote(v) + " is not " + want)
+}
+
+// configValue is one setting read from a file; problem is set when the
+// value has a type no setting takes.
+type configValue struct {
+	key, value, where, problem string
+}
+
+// LoadConfig applies Site.LoadConfig to the default site.
+func LoadConfig(path string) error {
+	return defaultSite.LoadConfig(path)
+}
+
+// LoadConfig reads path (SITE_CONFIG when path is empty; no file when both
+// are empty), then the SITE_* environment variables, e.g. SITE_CACHE_SIZE=5
+// or SITE_ROBOTS_DISALLOW=/tmp/,/drafts/. The environment overrides the
+// file, both override earlier setter calls, and setter calls after
+// LoadConfig override both.
+//
+// Files ending in .json hold one object; any other file holds TOML-style
+// `key = value` lines with # comments, quoted or bare strings and
+// ["a", "b"] lists. Keys are the snake_case Config fields (cache_size,
+// default_route, output_dir, dev_mode, precompress, content_security_policy,
+// base_url, base_path, robots_disallow, continue_on_render_error,
+// link_check, csrf, health_endpoints, metrics_path); unknown keys are
+// errors.
+//
+// Every problem is returned in one *ConfigError, which Mount and
+// BuildStatic also return until a later LoadConfig succeeds. Values are
+// validated (e.g. cache_size >= 0, default_route names a registered
+// module) at Mount and BuildStatic.
+func (s *Site) LoadConfig(path string) error {
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	var problems []string
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, v := range values {
+			if v.problem != "" {
+				problems = append(problems, v.where+": "+v.problem)
+			} else if p := s.applyConfig(v.key, v.value); p != "" {
+				problems = append(problems, v.where+": "+p)
+			}
+		}
+	}
+	for _, k := range configKeys {
+		env := "SITE_" + strings.ToUpper(k.key)
+		if v, ok := os.LookupEnv(env); ok {
+			if p := s.applyConfig(k.key, v); p != "" {
+				problems = append(problems, env+": "+p)
+			}
+		}
+	}
+	s.configLoad = problems
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// WithConfig is the Option form of Site.LoadConfig; its error is returned
+// by Mount and BuildStatic.
+func WithConfig(path string) Option {
+	return func(s *Site) { s.LoadConfig(path) }
+}
+
+// applyConfig sets key to v and returns the problem, or "".
+func (s *Site) applyConfig(key, v string) string {
+	for _, k := range configKeys {
+		if k.key == key {
+			if err := k.set(s, v); err != nil {
+				return err.Error()
+			}
+			return ""
+		}
+	}
+	return "unknown key " + strconv.Quote(key)
+}
+
+// checkConfig returns the LoadConfig, configuration and registration
+// problems as one *ConfigError, or nil.
+func (s *Site) checkConfig() error {
+	return s.validate(slices.Clone(s.configLoad)...)
+}
+
+// readConfigFile returns the settings of a JSON or TOML-style file.
+func readConfigFile(path string) ([]configValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".json" {
+		return parseJSONConfig(path, data)
+	}
+	return parseTOMLConfig(path, data)
+}
+
+func parseJSONConfig(path string, data []byte) ([]configValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Err(path + ": " + err.Error())
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]configValue, 0, len(keys))
+	for _, k := range keys {
+		v := configValue{key: k, where: path + ": " + k}
+		switch x := obj[k].(type) {
+		case string:
+			v.value = x
+		case bool:
+			v.value = strconv.FormatBool(x)
+		case json.Number:
+			v.value = x.String()
+		case []any:
+			items := make([]string, len(x))
+			for i, item := range x {
+				s, ok := item.(string)
+				if !ok {
+					v.problem = "list items must be strings"
+				}
+				items[i] = s
+			}
+			v.value = strings.Join(items, ",")
+		default:
+			v.problem = "must be a string, number, boolean or list of strings"
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func parseTOMLConfig(path string, data []byte) ([]configValue, error) {
+	var values []configValue
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		where := path + ":" + strconv.Itoa(n)
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return values, fmt.Err(where + ": expected key = value")
+		}
+		v, err := tomlValue(strings.TrimSpace(raw))
+		if err != nil {
+			return values, fmt.Err(where + ": " + err.Error())
+		}
+		values = append(values, configValue{key: strings.TrimSpace(key), value: v, where: where})
+	}
+	return values, sc.Err()
+}
+
+// tomlValue unquotes a string, joins a list with commas and strips a
+// trailing comment from bare values.
+func tomlValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		v, err := strconv.QuotedPrefix(raw)
+		if err != nil {
+			return "", errValue(raw, "a quoted string")
+		}
+		if rest := strings.TrimSpace(raw[len(v):]); rest != "" && rest[0] != '#' {
+			return "", errValue(raw, "a quoted string")
+		}
+		return strconv.Unquote(v)
+	case strings.HasPrefix(raw, "["):
+		end := strings.LastIndex(raw, "]")
+		if end < 0 {
+			return "", errValue(raw, "a list")
+		}
+		var items []string
+		for _, item := range strings.Split(raw[1:end], ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return strings.Join(items, ","), nil
+	}
+	v, _, _ := strings.Cut(raw, "#")
+	return strings.TrimSpace(v), nil
+}
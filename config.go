@@ -1,38 +1,334 @@
 package site
 
-var (
-	config = &Config{
-		CacheSize:    3,
-		DefaultRoute: "home",
-		OutputDir:    "./public",
-		DevMode:      false,
+import "github.com/tinywasm/fmt"
+
+// defaultConfig returns the configuration of a new Site.
+func defaultConfig() *Config {
+	return &Config{
+		CacheSize:   3,
+		OutputDir:   "./public",
+		DevMode:     false,
+		Precompress: false,
 	}
-)
+}
 
 type Config struct {
 	CacheSize    int
 	DefaultRoute string
 	OutputDir    string
 	DevMode      bool
+	Precompress  bool
+	// ContentSecurityPolicy is the base policy; hashes of inline blocks are
+	// appended to script-src/style-src. Empty disables CSP output.
+	ContentSecurityPolicy string
+	// BaseURL is the absolute site URL used by sitemap.xml and robots.txt.
+	// Empty disables their generation.
+	BaseURL        string
+	RobotsDisallow []string
+	// ContinueOnRenderError logs render failures and substitutes the failing
+	// module instead of aborting Mount/BuildStatic.
+	ContinueOnRenderError bool
+	// LinkCheck makes BuildStatic verify internal links, sprite references
+	// and asset URLs in the generated HTML.
+	LinkCheck bool
+	// CSRF makes Mount require a double-submit token on unsafe requests.
+	CSRF bool
+	// HealthEndpoints makes Mount register /healthz, /readyz and /version.
+	HealthEndpoints bool
+	// MetricsPath is where Mount serves Prometheus metrics. Empty disables
+	// the endpoint and request metrics.
+	MetricsPath string
+	// BasePath is the URL path the site is served under (e.g. "/app"),
+	// without a trailing slash. Empty serves it at the root.
+	BasePath string
 }
 
 // SetCacheSize configures module cache size (default: 3)
+func (s *Site) SetCacheSize(size int) {
+	s.config.CacheSize = size
+}
+
+// SetCacheSize applies Site.SetCacheSize to the default site.
 func SetCacheSize(size int) {
-	config.CacheSize = size
+	defaultSite.SetCacheSize(size)
+}
+
+// WithCacheSize is the Option form of Site.SetCacheSize.
+func WithCacheSize(size int) Option {
+	return func(s *Site) { s.SetCacheSize(size) }
 }
 
-// SetDefaultRoute configures default route (default: "home")
+// SetDefaultRoute configures default route (default: "home" when registered,
+// else the first registered module)
+func (s *Site) SetDefaultRoute(route string) {
+	s.config.DefaultRoute = route
+}
+
+// SetDefaultRoute applies Site.SetDefaultRoute to the default site.
 func SetDefaultRoute(route string) {
-	config.DefaultRoute = route
+	defaultSite.SetDefaultRoute(route)
+}
+
+// WithDefaultRoute is the Option form of Site.SetDefaultRoute.
+func WithDefaultRoute(route string) Option {
+	return func(s *Site) { s.SetDefaultRoute(route) }
 }
 
 // SetOutputDir configures the output directory for assets (default: "./public")
+func (s *Site) SetOutputDir(dir string) {
+	s.config.OutputDir = dir
+}
+
+// SetOutputDir applies Site.SetOutputDir to the default site.
 func SetOutputDir(dir string) {
-	config.OutputDir = dir
+	defaultSite.SetOutputDir(dir)
+}
+
+// WithOutputDir is the Option form of Site.SetOutputDir.
+func WithOutputDir(dir string) Option {
+	return func(s *Site) { s.SetOutputDir(dir) }
 }
 
 // SetDevMode configures development mode (default: false)
+func (s *Site) SetDevMode(enabled bool) {
+	s.config.DevMode = enabled
+	s.handler.DevMode = enabled
+}
+
+// SetDevMode applies Site.SetDevMode to the default site.
 func SetDevMode(enabled bool) {
-	config.DevMode = enabled
-	handler.DevMode = enabled
+	defaultSite.SetDevMode(enabled)
+}
+
+// WithDevMode is the Option form of Site.SetDevMode.
+func WithDevMode(enabled bool) Option {
+	return func(s *Site) { s.SetDevMode(enabled) }
+}
+
+// SetPrecompress enables gzip siblings for static builds and compressed
+// asset responses from Mount (default: false)
+func (s *Site) SetPrecompress(enabled bool) {
+	s.config.Precompress = enabled
+}
+
+// SetPrecompress applies Site.SetPrecompress to the default site.
+func SetPrecompress(enabled bool) {
+	defaultSite.SetPrecompress(enabled)
+}
+
+// WithPrecompress is the Option form of Site.SetPrecompress.
+func WithPrecompress(enabled bool) Option {
+	return func(s *Site) { s.SetPrecompress(enabled) }
+}
+
+// SetContentSecurityPolicy enables CSP output with the given base policy
+// (e.g. DefaultContentSecurityPolicy). Mount sends it as a header and
+// BuildStatic writes it as a <meta> tag. Empty disables it (default).
+func (s *Site) SetContentSecurityPolicy(policy string) {
+	s.config.ContentSecurityPolicy = policy
+}
+
+// SetContentSecurityPolicy applies Site.SetContentSecurityPolicy to the default site.
+func SetContentSecurityPolicy(policy string) {
+	defaultSite.SetContentSecurityPolicy(policy)
+}
+
+// WithContentSecurityPolicy is the Option form of Site.SetContentSecurityPolicy.
+func WithContentSecurityPolicy(policy string) Option {
+	return func(s *Site) { s.SetContentSecurityPolicy(policy) }
+}
+
+// SetBaseURL configures the absolute site URL (e.g. "https://example.com").
+// When set, BuildStatic and Mount generate sitemap.xml and robots.txt.
+func (s *Site) SetBaseURL(url string) {
+	s.config.BaseURL = url
+}
+
+// SetBaseURL applies Site.SetBaseURL to the default site.
+func SetBaseURL(url string) {
+	defaultSite.SetBaseURL(url)
+}
+
+// WithBaseURL is the Option form of Site.SetBaseURL.
+func WithBaseURL(url string) Option {
+	return func(s *Site) { s.SetBaseURL(url) }
+}
+
+// SetRobotsDisallow adds Disallow rules to robots.txt on top of the rules
+// generated for private modules.
+func (s *Site) SetRobotsDisallow(rules ...string) {
+	s.config.RobotsDisallow = rules
+}
+
+// SetRobotsDisallow applies Site.SetRobotsDisallow to the default site.
+func SetRobotsDisallow(rules ...string) {
+	defaultSite.SetRobotsDisallow(rules...)
+}
+
+// WithRobotsDisallow is the Option form of Site.SetRobotsDisallow.
+func WithRobotsDisallow(rules ...string) Option {
+	return func(s *Site) { s.SetRobotsDisallow(rules...) }
+}
+
+// SetContinueOnRenderError makes the SSR build log module render failures and
+// replace the failing module with an error section instead of returning a
+// *BuildError (default: false)
+func (s *Site) SetContinueOnRenderError(enabled bool) {
+	s.config.ContinueOnRenderError = enabled
+}
+
+// SetContinueOnRenderError applies Site.SetContinueOnRenderError to the default site.
+func SetContinueOnRenderError(enabled bool) {
+	defaultSite.SetContinueOnRenderError(enabled)
+}
+
+// WithContinueOnRenderError is the Option form of Site.SetContinueOnRenderError.
+func WithContinueOnRenderError(enabled bool) Option {
+	return func(s *Site) { s.SetContinueOnRenderError(enabled) }
+}
+
+// SetLinkCheck makes BuildStatic fail with a linkcheck.Problems error when a
+// generated page links to a missing module, file or sprite icon (default: false)
+func (s *Site) SetLinkCheck(enabled bool) {
+	s.config.LinkCheck = enabled
+}
+
+// SetLinkCheck applies Site.SetLinkCheck to the default site.
+func SetLinkCheck(enabled bool) {
+	defaultSite.SetLinkCheck(enabled)
+}
+
+// WithLinkCheck is the Option form of Site.SetLinkCheck.
+func WithLinkCheck(enabled bool) Option {
+	return func(s *Site) { s.SetLinkCheck(enabled) }
+}
+
+// SetCSRF protects crudp mutation routes with a double-submit token: Mount
+// issues a csrf_token cookie, the page sends it back as X-CSRF-Token on
+// same-origin POST/PUT/PATCH/DELETE, and cross-site Origin or Sec-Fetch-Site
+// requests are refused (default: false)
+func (s *Site) SetCSRF(enabled bool) {
+	s.config.CSRF = enabled
+}
+
+// SetCSRF applies Site.SetCSRF to the default site.
+func SetCSRF(enabled bool) {
+	defaultSite.SetCSRF(enabled)
+}
+
+// WithCSRF is the Option form of Site.SetCSRF.
+func WithCSRF(enabled bool) Option {
+	return func(s *Site) { s.SetCSRF(enabled) }
+}
+
+// SetHealthEndpoints makes Mount register /healthz (process alive), /readyz
+// (rbac, database, SSR build and ReadinessChecker handlers) and /version
+// (build info, modules and asset hashes) (default: false)
+func (s *Site) SetHealthEndpoints(enabled bool) {
+	s.config.HealthEndpoints = enabled
+}
+
+// SetHealthEndpoints applies Site.SetHealthEndpoints to the default site.
+func SetHealthEndpoints(enabled bool) {
+	defaultSite.SetHealthEndpoints(enabled)
+}
+
+// WithHealthEndpoints is the Option form of Site.SetHealthEndpoints.
+func WithHealthEndpoints(enabled bool) Option {
+	return func(s *Site) { s.SetHealthEndpoints(enabled) }
+}
+
+// SetMetricsPath makes Mount serve the Site.Metrics registry at path (e.g.
+// "/metrics") in the Prometheus text format, with crudp request counts and
+// latencies and asset bytes served (default: "", disabled)
+func (s *Site) SetMetricsPath(path string) {
+	s.config.MetricsPath = path
+}
+
+// SetMetricsPath applies Site.SetMetricsPath to the default site.
+func SetMetricsPath(path string) {
+	defaultSite.SetMetricsPath(path)
+}
+
+// WithMetricsPath is the Option form of Site.SetMetricsPath.
+func WithMetricsPath(path string) Option {
+	return func(s *Site) { s.SetMetricsPath(path) }
+}
+
+// SetBasePath serves the site under path (e.g. "/app"): Mount registers its
+// routes below it, page and asset URLs, the client.wasm loader and the
+// crudp endpoints of the wasm client are prefixed, and BuildStatic output
+// links to it. Only root-absolute links to the site itself (the root, its
+// assets and /name/... handler paths) are prefixed; /login and other host
+// links are kept. Crawlers read robots.txt only at the host root, so the
+// host must serve the generated /app/robots.txt there itself. Trailing
+// slashes are dropped (default: "", the root)
+func (s *Site) SetBasePath(path string) {
+	for len(path) > 0 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	s.config.BasePath = path
+}
+
+// SetBasePath applies Site.SetBasePath to the default site.
+func SetBasePath(path string) {
+	defaultSite.SetBasePath(path)
+}
+
+// WithBasePath is the Option form of Site.SetBasePath.
+func WithBasePath(path string) Option {
+	return func(s *Site) { s.SetBasePath(path) }
+}
+
+// ConfigError lists every problem found by LoadConfig or by the validation
+// at Mount: invalid configuration values and handler registrations.
+// Problems name the file key (e.g. "cache_size"), the file line, the SITE_*
+// variable or the handler.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	msg := "site: invalid config:"
+	for _, p := range e.Problems {
+		msg += "\n  " + p
+	}
+	return msg
+}
+
+// validateConfig returns the problems of the current configuration.
+// A DefaultRoute set explicitly must name a registered module once modules
+// are registered.
+func (s *Site) validateConfig() []string {
+	c := s.config
+	var problems []string
+	if c.CacheSize < 0 {
+		problems = append(problems, fmt.Sprintf("cache_size: must be at least 0, got %d", c.CacheSize))
+	}
+	if c.DefaultRoute != "" && len(s.handler.registeredModules) > 0 && s.findModule(c.DefaultRoute) == nil {
+		names := ""
+		for i, m := range s.handler.registeredModules {
+			if i > 0 {
+				names += ", "
+			}
+			names += m.name
+		}
+		problems = append(problems, "default_route: no module named \""+c.DefaultRoute+"\" (registered: "+names+")")
+	}
+	if c.OutputDir == "" {
+		problems = append(problems, "output_dir: must not be empty")
+	}
+	if c.BaseURL != "" && !fmt.HasPrefix(c.BaseURL, "http://") && !fmt.HasPrefix(c.BaseURL, "https://") {
+		problems = append(problems, "base_url: must be an absolute http(s) URL, got \""+c.BaseURL+"\"")
+	}
+	if c.BasePath != "" && (fmt.Contains(c.BasePath, "//") || fmt.Contains(c.BasePath, "?") || fmt.Contains(c.BasePath, "#")) {
+		problems = append(problems, "base_path: must be a plain URL path, got \""+c.BasePath+"\"")
+	}
+	if c.MetricsPath != "" && (!fmt.HasPrefix(c.MetricsPath, "/") || c.MetricsPath == "/") {
+		problems = append(problems, "metrics_path: must be a path below /, got \""+c.MetricsPath+"\"")
+	}
+	return problems
 }
@@ -0,0 +1,163 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// DefaultContentSecurityPolicy is a strict starting point for SetContentSecurityPolicy.
+// 'wasm-unsafe-eval' is required to instantiate the wasm client.
+const DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self' 'wasm-unsafe-eval'; style-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'self'"
+
+// metaIgnoredDirectives are not honoured by browsers in a <meta> policy.
+var metaIgnoredDirectives = []string{"frame-ancestors", "report-uri", "report-to", "sandbox"}
+
+// inlineHashes returns CSP hash sources for every inline script and style
+// block in page. External scripts (src=...) and data blocks such as
+// application/ld+json are skipped.
+func inlineHashes(page []byte) (scripts, styles []string) {
+	lower := bytes.ToLower(page)
+	for _, tag := range []string{"script", "style"} {
+		open := []byte("<" + tag)
+		closing := []byte("</" + tag)
+		for pos := 0; ; {
+			i := bytes.Index(lower[pos:], open)
+			if i < 0 {
+				break
+			}
+			start := pos + i
+			end := bytes.IndexByte(lower[start:], '>')
+			if end < 0 {
+				break
+			}
+			attrs := string(lower[start+len(open) : start+end])
+			bodyStart := start + end + 1
+			c := bytes.Index(lower[bodyStart:], closing)
+			if c < 0 {
+				break
+			}
+			body := page[bodyStart : bodyStart+c]
+			pos = bodyStart + c
+
+			if tag == "script" && (hasAttr(attrs, "src") || !isJavaScriptType(attrs)) {
+				continue
+			}
+			sum := sha256.Sum256(body)
+			src := "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+			if tag == "script" {
+				scripts = append(scripts, src)
+			} else {
+				styles = append(styles, src)
+			}
+		}
+	}
+	return scripts, styles
+}
+
+// hasAttr reports whether a lowercased attribute list contains name.
+func hasAttr(attrs, name string) bool {
+	for _, f := range strings.Fields(attrs) {
+		if f == name || strings.HasPrefix(f, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// isJavaScriptType reports whether a script tag executes as JavaScript.
+func isJavaScriptType(attrs string) bool {
+	for _, f := range strings.Fields(attrs) {
+		if v, ok := strings.CutPrefix(f, "type="); ok {
+			v = strings.Trim(v, `"'`)
+			return v == "" || v == "module" || strings.Contains(v, "javascript") || strings.Contains(v, "ecmascript")
+		}
+	}
+	return true
+}
+
+// buildCSP merges the inline hash sources into policy. Missing script-src or
+// style-src directives are derived from default-src (or 'self').
+func buildCSP(policy string, scripts, styles []string) string {
+	var names []string
+	directives := map[string][]string{}
+	for _, d := range strings.Split(policy, ";") {
+		f := strings.Fields(d)
+		if len(f) == 0 {
+			continue
+		}
+		name := strings.ToLower(f[0])
+		if _, seen := directives[name]; !seen {
+			names = append(names, name)
+		}
+		directives[name] = append(directives[name], f[1:]...)
+	}
+
+	add := func(name string, sources []string) {
+		if len(sources) == 0 {
+			return
+		}
+		if _, ok := directives[name]; !ok {
+			base, ok := directives["default-src"]
+			if !ok {
+				base = []string{"'self'"}
+			}
+			directives[name] = append([]string(nil), base...)
+			names = append(names, name)
+		}
+		for _, s := range sources {
+			if !contains(directives[name], s) {
+				directives[name] = append(directives[name], s)
+			}
+		}
+	}
+	add("script-src", scripts)
+	add("style-src", styles)
+
+	parts := make([]string, 0, len(names))
+	for _, n := range names {
+		parts = append(parts, strings.Join(append([]string{n}, directives[n]...), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// metaCSP returns a <meta> tag carrying policy, without the directives that
+// browsers ignore when delivered through markup.
+func metaCSP(policy string) string {
+	var kept []string
+	for _, d := range strings.Split(policy, ";") {
+		f := strings.Fields(d)
+		if len(f) == 0 || contains(metaIgnoredDirectives, strings.ToLower(f[0])) {
+			continue
+		}
+		kept = append(kept, strings.Join(f, " "))
+	}
+	return `<meta http-equiv="Content-Security-Policy" content="` + strings.Join(kept, "; ") + `">`
+}
+
+// pageCSP computes the policy for a rendered page.
+func (s *Site) pageCSP(page []byte) string {
+	scripts, styles := inlineHashes(page)
+	return buildCSP(s.config.ContentSecurityPolicy, scripts, styles)
+}
+
+// cspHandler sets the Content-Security-Policy header on every response.
+func cspHandler(next http.Handler, policy string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", policy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
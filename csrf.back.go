@@ -0,0 +1,118 @@
+//go:build !wasm
+
+package site
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// CSRFCookie holds the double-submit token; page scripts read it.
+	CSRFCookie = "csrf_token"
+	// CSRFHeader carries the token on unsafe requests.
+	CSRFHeader = "X-CSRF-Token"
+	// CSRFPath returns the token as {"token":"..."} and sets the cookie, for
+	// clients that are not loaded from the site page.
+	CSRFPath = "/__site/csrf"
+)
+
+// csrfScript wraps window.fetch so every same-origin unsafe request, the
+// wasm client's crudp calls included, sends the cookie token as CSRFHeader.
+const csrfScript = `<script>(function(){var f=window.fetch;window.fetch=function(input,init){init=init||{};var m=(init.method||(input&&input.method)||"GET").toUpperCase();if(m!=="GET"&&m!=="HEAD"&&m!=="OPTIONS"&&new URL((input&&input.url)||input,location.href).origin===location.origin){var t=(document.cookie.match(/(?:^|; )` + CSRFCookie + `=([^;]*)/)||[])[1];if(t){var h=new Headers(init.headers||(input&&input.headers)||undefined);h.set("` + CSRFHeader + `",decodeURIComponent(t));init.headers=h}}return f.call(this,input,init)}})();</script>`
+
+// SetCSRFExempt skips the CSRF check for requests matching fn, typically API
+// clients authenticated by a token rather than a cookie:
+//
+//	site.SetCSRFExempt(func(r *http.Request) bool {
+//		return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+//	})
+func (s *Site) SetCSRFExempt(fn func(r *http.Request) bool) {
+	s.csrfExempt = fn
+}
+
+// SetCSRFExempt applies Site.SetCSRFExempt to the default site.
+func SetCSRFExempt(fn func(r *http.Request) bool) {
+	defaultSite.SetCSRFExempt(fn)
+}
+
+// WithCSRFExempt is the Option form of Site.SetCSRFExempt.
+func WithCSRFExempt(fn func(r *http.Request) bool) Option {
+	return func(s *Site) { s.SetCSRFExempt(fn) }
+}
+
+// csrfHandler issues the token cookie on safe requests and checks unsafe
+// ones, when CSRF is enabled.
+func (s *Site) csrfHandler(next http.Handler) http.Handler {
+	if !s.config.CSRF {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			if c, err := r.Cookie(CSRFCookie); err != nil || c.Value == "" {
+				r = r.Clone(r.Context())
+				r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: issueCSRFToken(w, r, s.config.BasePath+"/")})
+			}
+		default:
+			if s.csrfExempt == nil || !s.csrfExempt(r) {
+				if reason := csrfReject(r); reason != "" {
+					http.Error(w, "Forbidden: "+reason, http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// csrfReject returns why an unsafe request fails the CSRF check, or "".
+func csrfReject(r *http.Request) string {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return "cross-site request"
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(u.Host, r.Host) {
+			return "cross-origin request"
+		}
+	}
+	c, err := r.Cookie(CSRFCookie)
+	if err != nil || c.Value == "" {
+		return "missing CSRF cookie"
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Value), []byte(r.Header.Get(CSRFHeader))) != 1 {
+		return "invalid CSRF token"
+	}
+	return ""
+}
+
+// issueCSRFToken sets a new token cookie for path and returns the token.
+func issueCSRFToken(w http.ResponseWriter, r *http.Request, path string) string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookie,
+		Value:    token,
+		Path:     path,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+	})
+	return token
+}
+
+// csrfTokenHandler serves CSRFPath; csrfHandler has set the cookie.
+func csrfTokenHandler(w http.ResponseWriter, r *http.Request) {
+	c, _ := r.Cookie(CSRFCookie)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"token": c.Value})
+}
@@ -6,16 +6,15 @@ require (
 	github.com/tinywasm/assetmin v0.2.1
 	github.com/tinywasm/client v0.5.50
 	github.com/tinywasm/dom v0.5.6
+	github.com/tinywasm/fetch v0.1.17
 )
 
 require (
 	al.essio.dev/pkg/shellescape v1.6.0 // indirect
 	github.com/danieljoos/wincred v1.2.3 // indirect
 	github.com/godbus/dbus/v5 v5.2.2 // indirect
-	github.com/tinywasm/binary v0.5.11 // indirect
 	github.com/tinywasm/context v0.0.12 // indirect
 	github.com/tinywasm/devflow v0.2.22 // indirect
-	github.com/tinywasm/fetch v0.1.17 // indirect
 	github.com/tinywasm/gobuild v0.0.24 // indirect
 	github.com/tinywasm/mcp v0.0.0-20260222182815-eed752284ce7 // indirect
 	github.com/tinywasm/mcpserve v0.0.28 // indirect
@@ -30,6 +29,7 @@ require (
 require (
 	github.com/tdewolff/minify/v2 v2.24.8 // indirect
 	github.com/tdewolff/parse/v2 v2.8.5 // indirect
+	github.com/tinywasm/binary v0.5.11
 	github.com/tinywasm/crudp v0.2.11
 	github.com/tinywasm/fmt v0.18.4
 	github.com/tinywasm/rbac v0.0.1
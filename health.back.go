@@ -0,0 +1,201 @@
+//go:build !wasm
+
+package site
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinywasm/fmt"
+)
+
+// Probe paths registered by Mount with SetHealthEndpoints(true).
+const (
+	HealthPath  = "/healthz"
+	ReadyPath   = "/readyz"
+	VersionPath = "/version"
+)
+
+// readyTimeout bounds all readiness checks of one /readyz request.
+const readyTimeout = 5 * time.Second
+
+// ReadinessChecker is an optional interface for handlers that depend on
+// something external (a queue, a cache...). /readyz reports 503 while Ready
+// returns an error.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// ReadyReport is the /readyz response body.
+type ReadyReport struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"` // check name -> "ok" or "<check>: failing"
+}
+
+// VersionInfo is the /version response body.
+type VersionInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`     // main package path
+	Version   string            `json:"version,omitempty"`  // main module version
+	Settings  map[string]string `json:"settings,omitempty"` // vcs.* (revision, time, modified), GOOS and GOARCH
+	Modules   []string          `json:"modules"`            // registered site modules
+	Assets    map[string]string `json:"assets"`             // route -> SHA-256 of the served body
+}
+
+// registerHealth adds the probe routes to mux. assets serves the page and
+// asset routes hashed by /version.
+func (s *Site) registerHealth(mux *http.ServeMux, assets http.Handler) {
+	mux.HandleFunc("GET "+HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET "+ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		report := s.readiness(ctx)
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeProbeJSON(w, status, report)
+	})
+	version := sync.OnceValue(func() VersionInfo { return s.versionInfo(assets) })
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, r *http.Request) {
+		writeProbeJSON(w, http.StatusOK, version())
+	})
+}
+
+// readiness runs every check: rbac and the database when SetDB was called,
+// whether the last ssrBuild completed, and each ReadinessChecker handler.
+// /readyz is public, so errors are logged and reported as "<check>: failing".
+func (s *Site) readiness(ctx context.Context) ReadyReport {
+	report := ReadyReport{Ready: true, Checks: make(map[string]string)}
+	check := func(name string, err error) {
+		if err != nil {
+			s.log().Warn("site: readiness check failing", "check", name, "err", err)
+			report.Ready = false
+			report.Checks[name] = name + ": failing"
+			return
+		}
+		report.Checks[name] = "ok"
+	}
+	if s.rbac.db != nil {
+		check("rbac", errIf(!s.rbac.initialized, "rbac not initialized"))
+		check("db", s.rbac.ping.ping(ctx, s.rbac.db))
+	}
+	// Failures kept by SetContinueOnRenderError still served a page
+	aborted := s.lastReport == nil || (len(s.lastReport.Failures) > 0 && !s.config.ContinueOnRenderError)
+	check("ssr", errIf(aborted, "ssr build failed"))
+	for _, h := range s.handler.handlers {
+		if rc, ok := h.(ReadinessChecker); ok {
+			check(h.(interface{ HandlerName() string }).HandlerName(), rc.Ready(ctx))
+		}
+	}
+	return report
+}
+
+// dbPinger checks the database for /readyz. Executors without PingContext
+// cannot be cancelled, so at most one such ping runs at a time: probes that
+// arrive meanwhile wait for it instead of starting another.
+type dbPinger struct {
+	mu       sync.Mutex
+	inFlight *pingFlight
+}
+
+// pingFlight is one running ping; err is set before done is closed.
+type pingFlight struct {
+	done chan struct{}
+	err  error
+}
+
+// ping uses the executor's PingContext method when it has one. Otherwise it
+// joins or starts the in-flight Ping (or SELECT 1) and gives up waiting when
+// ctx ends.
+func (p *dbPinger) ping(ctx context.Context, db DBExecutor) error {
+	if pc, ok := db.(interface{ PingContext(context.Context) error }); ok {
+		return pc.PingContext(ctx)
+	}
+	p.mu.Lock()
+	f := p.inFlight
+	if f == nil {
+		f = &pingFlight{done: make(chan struct{})}
+		p.inFlight = f
+		go func() {
+			f.err = pingNoContext(db)
+			p.mu.Lock()
+			p.inFlight = nil
+			p.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	p.mu.Unlock()
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pingNoContext uses the executor's Ping method, or runs SELECT 1.
+func pingNoContext(db DBExecutor) error {
+	if p, ok := db.(interface{ Ping() error }); ok {
+		return p.Ping()
+	}
+	var n int
+	return db.QueryRow("SELECT 1").Scan(&n)
+}
+
+// versionInfo collects build info, site modules and asset hashes. Build
+// flags (-ldflags may carry secrets) and dependency versions stay private.
+func (s *Site) versionInfo(assets http.Handler) VersionInfo {
+	v := VersionInfo{GoVersion: runtime.Version(), Assets: make(map[string]string)}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		v.Path, v.Version = bi.Path, bi.Main.Version
+		v.Settings = make(map[string]string)
+		for _, st := range bi.Settings {
+			if strings.HasPrefix(st.Key, "vcs.") || st.Key == "GOOS" || st.Key == "GOARCH" {
+				v.Settings[st.Key] = st.Value
+			}
+		}
+	}
+	for _, m := range s.handler.registeredModules {
+		v.Modules = append(v.Modules, m.name)
+	}
+	for _, route := range assetRoutes {
+		if body, err := renderRoute(assets, route); err == nil {
+			sum := sha256.Sum256(body)
+			v.Assets[route] = hex.EncodeToString(sum[:])
+		}
+	}
+	return v
+}
+
+// errIf returns an error with msg when cond holds.
+func errIf(cond bool, msg string) error {
+	if cond {
+		return fmt.Err(msg)
+	}
+	return nil
+}
+
+func writeProbeJSON(w http.ResponseWriter, status int, body any) {
+	data, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,20 @@
+// Package httpenc negotiates response content encodings. It depends only on
+// the standard library so both package site (Mount) and cmd/sitebuild serve
+// can use it.
+package httpenc
+
+import "strings"
+
+// AcceptsEncoding reports whether the Accept-Encoding header allows enc.
+// A q=0 parameter refuses it.
+func AcceptsEncoding(header, enc string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), enc) {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		return params != "q=0" && params != "q=0.0" && params != "q=0.00" && params != "q=0.000"
+	}
+	return false
+}
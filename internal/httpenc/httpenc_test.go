@@ -0,0 +1,22 @@
+package httpenc
+
+import "testing"
+
+func TestAcceptsEncoding(t *testing.T) {
+	for _, c := range []struct {
+		header, enc string
+		want        bool
+	}{
+		{"gzip, br", "br", true},
+		{"GZIP", "gzip", true},
+		{"br;q=0, gzip", "br", false},
+		{"gzip; q=0.000", "gzip", false},
+		{"gzip;q=0.5", "gzip", true},
+		{"deflate", "gzip", false},
+		{"", "gzip", false},
+	} {
+		if got := AcceptsEncoding(c.header, c.enc); got != c.want {
+			t.Errorf("AcceptsEncoding(%q, %q) = %v, want %v", c.header, c.enc, got, c.want)
+		}
+	}
+}
@@ -3,30 +3,44 @@ package site
 // TestResetHandler resets the global handler state for testing.
 // For testing purposes only.
 func TestResetHandler() {
-	handler.registeredModules = nil
-	handler.DevMode = false
+	defaultSite.handler.registeredModules = nil
+	defaultSite.handler.handlers = nil
+	defaultSite.handler.unnamed = nil
+	defaultSite.handler.DevMode = false
 }
 
 // TestIsDevMode returns the current DevMode state of the handler.
 // For testing purposes only.
 func TestIsDevMode() bool {
-	return handler.DevMode
+	return defaultSite.handler.DevMode
 }
 
 // TestGetConfig returns the global configuration.
 // For testing purposes only.
 func TestGetConfig() *Config {
-	return config
+	return defaultSite.config
+}
+
+// TestSiteConfig returns the configuration of s.
+// For testing purposes only.
+func TestSiteConfig(s *Site) *Config {
+	return s.config
 }
 
 // TestParseRoute exposes the internal parseRoute function for testing.
 // For testing purposes only.
 func TestParseRoute(hash string) (module string, params []string) {
-	return parseRoute(hash)
+	return defaultSite.parseRoute(hash)
+}
+
+// TestDefaultRoute returns the module s shows for an empty hash.
+// For testing purposes only.
+func TestDefaultRoute(s *Site) string {
+	return s.defaultRoute()
 }
 
 // TestGetModules returns the list of registered modules.
 // For testing purposes only.
 func TestGetModules() []*registeredModule {
-	return handler.registeredModules
+	return defaultSite.handler.registeredModules
 }
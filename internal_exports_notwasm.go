@@ -2,10 +2,69 @@
 
 package site
 
-import "github.com/tinywasm/assetmin"
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tinywasm/assetmin"
+)
 
 // TestSSRBuild exposes the internal ssrBuild function for testing.
 // For testing purposes only.
 func TestSSRBuild(am *assetmin.AssetMin) error {
-	return ssrBuild(am)
+	return defaultSite.ssrBuild(am)
+}
+
+// TestHandlerVerbs exposes the CRUD verb detection used by Routes.
+// For testing purposes only.
+func TestHandlerVerbs(h any) []string {
+	return handlerVerbs(h)
+}
+
+// TestServeListener runs the ServeContext loop of s with h on ln.
+// For testing purposes only.
+func TestServeListener(s *Site, ctx context.Context, ln net.Listener, h http.Handler, opts ...ServeOption) error {
+	return s.serveListener(ctx, ln, h, opts...)
+}
+
+// TestRateLimiter returns the take and size operations of a bucket store
+// holding at most max keys, read with the clock now.
+// For testing purposes only.
+func TestRateLimiter(max int, now func() time.Time) (take func(key string, limit RateLimit) time.Duration, size func() int) {
+	l := newRateLimiter(max)
+	l.now = now
+	return func(key string, limit RateLimit) time.Duration {
+		return l.take(bucketLimit{key, limit})
+	}, l.size
+}
+
+// TestRateLimitHandler adds handlers to s, registering them with crudp in
+// dev mode only, and wraps next with the resulting rate limits.
+// For testing purposes only.
+func TestRateLimitHandler(s *Site, next http.Handler, handlers ...any) http.Handler {
+	testAddHandlers(s, handlers...)
+	return s.rateLimitHandler(next)
+}
+
+// TestMetricsHandler adds handlers to s like TestRateLimitHandler and wraps
+// next with the request metrics.
+// For testing purposes only.
+func TestMetricsHandler(s *Site, next http.Handler, handlers ...any) http.Handler {
+	testAddHandlers(s, handlers...)
+	return s.metricsHandler(next)
+}
+
+func testAddHandlers(s *Site, handlers ...any) {
+	s.handler.handlers = append(s.handler.handlers, handlers...)
+	s.handler.cp.SetDevMode(true)
+	s.handler.cp.RegisterHandlers(handlers...)
+}
+
+// TestPingDB returns the /readyz database check of a fresh dbPinger.
+// For testing purposes only.
+func TestPingDB() func(ctx context.Context, db DBExecutor) error {
+	var p dbPinger
+	return p.ping
 }
@@ -5,6 +5,6 @@ package site
 // TestResetWasm resets the active module and cache for testing.
 // For testing purposes only.
 func TestResetWasm() {
-	activeModule = nil
-	cache = nil
+	defaultSite.activeModule = nil
+	defaultSite.cache = nil
 }
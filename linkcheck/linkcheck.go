@@ -0,0 +1,370 @@
+// Package linkcheck verifies internal references in static site output:
+// hash links to modules (href="#module/params"), path links, asset URLs and
+// SVG sprite <use> references. It depends only on the standard library so
+// both package site (BuildStatic) and cmd/sitebuild can use it.
+package linkcheck
+
+import (
+	"html"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem is one reference that does not resolve.
+type Problem struct {
+	File   string // path relative to the checked directory, slash-separated
+	Line   int
+	URL    string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return p.File + ":" + strconv.Itoa(p.Line) + ": " + strconv.Quote(p.URL) + ": " + p.Reason
+}
+
+// Problems is returned as an error when any reference is broken.
+type Problems []Problem
+
+func (ps Problems) Error() string {
+	var sb strings.Builder
+	sb.WriteString("linkcheck: " + strconv.Itoa(len(ps)) + " broken reference(s):")
+	for _, p := range ps {
+		sb.WriteString("\n  " + p.String())
+	}
+	return sb.String()
+}
+
+// urlAttrs lists, per attribute, the tags whose value is a URL to check.
+var urlAttrs = map[string]map[string]bool{
+	"href":       {"a": true, "area": true, "link": true, "use": true},
+	"xlink:href": {"use": true},
+	"src":        {"script": true, "img": true, "iframe": true, "source": true, "audio": true, "video": true, "embed": true, "track": true},
+	"poster":     {"video": true},
+	"action":     {"form": true},
+}
+
+// Dir checks every .html file below dir. modules are the registered handler
+// names that hash links (#name/...) and path-mode links (/name/...) may target.
+// The error is non-nil only when the directory cannot be read.
+func Dir(dir string, modules []string) (Problems, error) {
+	return DirAt(dir, "", modules)
+}
+
+// DirAt is Dir for output served under the URL path base (e.g. "/app"):
+// root-absolute references must start with base, which maps to dir.
+func DirAt(dir, base string, modules []string) (Problems, error) {
+	c := &checker{dir: dir, base: strings.TrimSuffix(base, "/"), modules: make(map[string]bool), svgIDs: make(map[string]map[string]bool)}
+	for _, m := range modules {
+		c.modules[m] = true
+	}
+	var problems Problems
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".html" {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		problems = append(problems, c.checkHTML(filepath.ToSlash(rel), string(data))...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+type checker struct {
+	dir     string
+	base    string // URL path dir is served under, "" for the root
+	modules map[string]bool
+	svgIDs  map[string]map[string]bool // file -> ids, cached
+}
+
+// checkHTML checks the references of one page; name is relative to c.dir.
+func (c *checker) checkHTML(name, page string) []Problem {
+	tags := scan(page)
+	ids := make(map[string]bool)
+	for _, t := range tags {
+		for _, a := range t.attrs {
+			if a.name == "id" {
+				ids[a.value] = true
+			}
+		}
+	}
+
+	var problems []Problem
+	for _, t := range tags {
+		for _, a := range t.attrs {
+			if !urlAttrs[a.name][t.name] {
+				continue
+			}
+			if reason := c.resolve(name, t.name, a.value, ids); reason != "" {
+				problems = append(problems, Problem{File: name, Line: lineAt(page, a.offset), URL: a.value, Reason: reason})
+			}
+		}
+	}
+	return problems
+}
+
+// resolve returns why ref, found in page name on tag, does not resolve, or "".
+func (c *checker) resolve(name, tag, ref string, ids map[string]bool) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || ref == "#" || isExternal(ref) {
+		return ""
+	}
+
+	if frag, ok := strings.CutPrefix(ref, "#"); ok {
+		if tag == "use" {
+			if ids[frag] || c.fileHasID("icons.svg", frag) {
+				return ""
+			}
+			return "no sprite symbol with id " + strconv.Quote(frag)
+		}
+		// #users/42 and #/users/42 both route to module users; only a
+		// registered module satisfies them, not a leftover element id
+		module, _, routed := strings.Cut(strings.TrimPrefix(frag, "/"), "/")
+		routed = routed || strings.HasPrefix(frag, "/")
+		if module == "" || c.modules[module] || (!routed && ids[frag]) {
+			return ""
+		}
+		if routed {
+			return "no module named " + strconv.Quote(module)
+		}
+		return "no module or element named " + strconv.Quote(module)
+	}
+
+	target, frag, _ := strings.Cut(ref, "#")
+	target, _, _ = strings.Cut(target, "?")
+	if !strings.HasPrefix(target, "/") {
+		target = path.Join(path.Dir("/"+name), target)
+	} else if c.base != "" {
+		rest, ok := strings.CutPrefix(target, c.base)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return "outside base path " + strconv.Quote(c.base)
+		}
+		target = "/" + rest
+	}
+	target = path.Clean(target)
+
+	file := c.file(target)
+	if file == "" {
+		// Path-mode route: /module/params is served by index.html
+		first, _, _ := strings.Cut(strings.TrimPrefix(target, "/"), "/")
+		if path.Ext(target) == "" && c.modules[first] {
+			return ""
+		}
+		return "no file " + strconv.Quote(target) + " in output"
+	}
+	if frag != "" && (tag == "use" || path.Ext(file) == ".svg") && !c.fileHasID(file, frag) {
+		return "no element with id " + strconv.Quote(frag) + " in " + file
+	}
+	return ""
+}
+
+// file returns the output file a site path refers to, relative to c.dir.
+func (c *checker) file(p string) string {
+	rel := strings.TrimPrefix(p, "/")
+	for _, candidate := range []string{rel, path.Join(rel, "index.html")} {
+		if candidate == "" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(c.dir, filepath.FromSlash(candidate)))
+		if err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// fileHasID reports whether an output file declares id.
+func (c *checker) fileHasID(file, id string) bool {
+	ids, ok := c.svgIDs[file]
+	if !ok {
+		ids = make(map[string]bool)
+		if data, err := os.ReadFile(filepath.Join(c.dir, filepath.FromSlash(file))); err == nil {
+			for _, t := range scan(string(data)) {
+				for _, a := range t.attrs {
+					if a.name == "id" {
+						ids[a.value] = true
+					}
+				}
+			}
+		}
+		c.svgIDs[file] = ids
+	}
+	return ids[id]
+}
+
+func isExternal(ref string) bool {
+	if strings.HasPrefix(ref, "//") {
+		return true
+	}
+	// A scheme is letters, digits, +, - or . before the first ':'
+	i := strings.IndexByte(ref, ':')
+	if i <= 0 {
+		return false
+	}
+	for _, r := range ref[:i] {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func lineAt(s string, offset int) int {
+	return strings.Count(s[:offset], "\n") + 1
+}
+
+type attr struct {
+	name   string
+	value  string
+	offset int // position of the attribute name in the document
+}
+
+type tag struct {
+	name  string
+	attrs []attr
+}
+
+// scan returns the start tags of an HTML or SVG document with their
+// attributes. Comments, doctypes and the bodies of script and style elements
+// are skipped.
+func scan(doc string) []tag {
+	var tags []tag
+	lower := asciiLower(doc)
+	pos := 0
+	for {
+		i := strings.IndexByte(doc[pos:], '<')
+		if i < 0 {
+			return tags
+		}
+		pos += i
+		switch {
+		case strings.HasPrefix(doc[pos:], "<!--"):
+			end := strings.Index(doc[pos+4:], "-->")
+			if end < 0 {
+				return tags
+			}
+			pos += 4 + end + 3
+			continue
+		case strings.HasPrefix(doc[pos:], "<!"), strings.HasPrefix(doc[pos:], "<?"), strings.HasPrefix(doc[pos:], "</"):
+			end := strings.IndexByte(doc[pos:], '>')
+			if end < 0 {
+				return tags
+			}
+			pos += end + 1
+			continue
+		}
+
+		j := pos + 1
+		if j >= len(doc) || !isLetter(doc[j]) {
+			pos++
+			continue
+		}
+		for j < len(doc) && isNameChar(doc[j]) {
+			j++
+		}
+		t := tag{name: lower[pos+1 : j]}
+		j = scanAttrs(doc, lower, j, &t)
+		tags = append(tags, t)
+		pos = j
+
+		if t.name == "script" || t.name == "style" {
+			end := strings.Index(lower[pos:], "</"+t.name)
+			if end < 0 {
+				return tags
+			}
+			pos += end
+		}
+	}
+}
+
+// scanAttrs parses attributes from doc[j:] up to the end of the tag and
+// returns the position after it.
+func scanAttrs(doc, lower string, j int, t *tag) int {
+	for j < len(doc) {
+		for j < len(doc) && (isSpace(doc[j]) || doc[j] == '/') {
+			j++
+		}
+		if j >= len(doc) {
+			return j
+		}
+		if doc[j] == '>' {
+			return j + 1
+		}
+		start := j
+		for j < len(doc) && !isSpace(doc[j]) && doc[j] != '=' && doc[j] != '>' && doc[j] != '/' {
+			j++
+		}
+		a := attr{name: lower[start:j], offset: start}
+		for j < len(doc) && isSpace(doc[j]) {
+			j++
+		}
+		if j < len(doc) && doc[j] == '=' {
+			j++
+			for j < len(doc) && isSpace(doc[j]) {
+				j++
+			}
+			if j < len(doc) && (doc[j] == '"' || doc[j] == '\'') {
+				q := doc[j]
+				end := strings.IndexByte(doc[j+1:], q)
+				if end < 0 {
+					return len(doc)
+				}
+				a.value = doc[j+1 : j+1+end]
+				j += end + 2
+			} else {
+				vs := j
+				for j < len(doc) && !isSpace(doc[j]) && doc[j] != '>' {
+					j++
+				}
+				a.value = doc[vs:j]
+			}
+			a.value = html.UnescapeString(a.value)
+		}
+		if a.name != "" {
+			t.attrs = append(t.attrs, a)
+		} else {
+			j++
+		}
+	}
+	return j
+}
+
+// asciiLower lowercases ASCII letters only, so offsets into the result match doc.
+func asciiLower(doc string) string {
+	b := []byte(doc)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func isNameChar(b byte) bool {
+	return isLetter(b) || b >= '0' && b <= '9' || b == '-' || b == ':' || b == '_'
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
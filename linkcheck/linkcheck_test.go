@@ -0,0 +1,112 @@
+package linkcheck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const page = `<!doctype html>
+<html><head>
+<link rel="stylesheet" href="/style.css">
+<link rel="icon" href="/favicon.svg">
+</head>
+<body>
+<!-- <a href="#commented-out">ignored</a> -->
+<svg class="sprite-icons"><defs><symbol id="icon-home"></symbol></defs></svg>
+<a href="#users/42">user</a> <a href="#/contact">contact</a> <a href="#top">top</a>
+<a id="top" href="https://example.com/x">external</a> <a href="mailto:a@b.c">mail</a>
+<a href="#usrs/42">renamed</a> <div id="usrs">stale id</div>
+<a href='/users/7'>path mode</a> <a href="/docs/">docs</a>
+<svg><use href="#icon-home"></use><use xlink:href="#icon-gone"></use></svg>
+<svg><use href="/icons.svg#icon-user"></use><use href="/icons.svg#icon-nope"></use></svg>
+<img src="/logo.png" alt="missing">
+<script>var s = '<a href="#inside-script">';</script>
+<script src="/script.js"></script>
+</body></html>`
+
+func TestDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"index.html":      page,
+		"docs/index.html": `<a href="../style.css">up</a><a href="guide.html">missing</a>`,
+		"style.css":       "a{}",
+		"script.js":       "",
+		"favicon.svg":     "<svg/>",
+		"icons.svg":       `<svg><symbol id="icon-user"></symbol></svg>`,
+	})
+
+	problems, err := Dir(dir, []string{"users", "contact"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`docs/index.html:1: "guide.html": no file "/docs/guide.html" in output`,
+		`index.html:11: "#usrs/42": no module named "usrs"`,
+		`index.html:13: "#icon-gone": no sprite symbol with id "icon-gone"`,
+		`index.html:14: "/icons.svg#icon-nope": no element with id "icon-nope" in icons.svg`,
+		`index.html:15: "/logo.png": no file "/logo.png" in output`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(problems), len(want), problems)
+	}
+	for i, p := range problems {
+		if p.String() != want[i] {
+			t.Errorf("problem %d = %s\nwant         %s", i, p, want[i])
+		}
+	}
+	if msg := problems.Error(); !strings.HasPrefix(msg, "linkcheck: 5 broken reference(s):") {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+func TestDirClean(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"index.html": `<a href="#">home</a><a href="#/">home</a><a href="/">root</a><a href="/?q=1">query</a>`,
+	})
+	problems, err := Dir(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestDirAt(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"index.html": `<link href="/app/style.css"><a href="/app/">home</a><a href="/app/users/7">path mode</a><a href="/style.css">root</a><a href="/apple/x">sibling</a>`,
+		"style.css":  "a{}",
+	})
+	problems, err := DirAt(dir, "/app/", []string{"users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`index.html:1: "/style.css": outside base path "/app"`,
+		`index.html:1: "/apple/x": outside base path "/app"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(problems), len(want), problems)
+	}
+	for i, p := range problems {
+		if p.String() != want[i] {
+			t.Errorf("problem %d = %s\nwant         %s", i, p, want[i])
+		}
+	}
+}
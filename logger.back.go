@@ -0,0 +1,32 @@
+//go:build !wasm
+
+package site
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// defaultLogger is read on every entry so slog.SetDefault applies.
+func defaultLogger() Logger {
+	return slog.Default()
+}
+
+type loggerKey struct{}
+
+// loggerHandler stores the site Logger in the request context for
+// middleware that has no Site (see Recover).
+func (s *Site) loggerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loggerKey{}, s.log())))
+	})
+}
+
+// loggerFrom returns the logger Mount stored in ctx, or the default.
+func loggerFrom(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return defaultLogger()
+}
@@ -0,0 +1,32 @@
+//go:build wasm
+
+package site
+
+import (
+	"syscall/js"
+
+	"github.com/tinywasm/fmt"
+)
+
+// consoleLogger writes entries to the browser console at the matching level.
+type consoleLogger struct{}
+
+func defaultLogger() Logger { return consoleLogger{} }
+
+func (consoleLogger) Debug(msg string, args ...any) { consoleLog("debug", msg, args) }
+func (consoleLogger) Info(msg string, args ...any)  { consoleLog("info", msg, args) }
+func (consoleLogger) Warn(msg string, args ...any)  { consoleLog("warn", msg, args) }
+func (consoleLogger) Error(msg string, args ...any) { consoleLog("error", msg, args) }
+
+// consoleLog prints msg followed by key=value pairs.
+func consoleLog(level, msg string, args []any) {
+	line := msg
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			line += " " + fmt.Sprint(args[i]) + "=" + fmt.Sprint(args[i+1])
+		} else {
+			line += " " + fmt.Sprint(args[i])
+		}
+	}
+	js.Global().Get("console").Call(level, line)
+}
@@ -0,0 +1,35 @@
+package site
+
+// Logger receives the site's log entries: registration, RBAC, SSR build,
+// serving and navigation. args are alternating key/value pairs (handler,
+// phase, user, route, err...), as in log/slog; *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// SetLogger routes the site's log entries to l. nil restores the default:
+// slog.Default() on the server, the browser console on wasm.
+func (s *Site) SetLogger(l Logger) {
+	s.logger = l
+}
+
+// SetLogger applies Site.SetLogger to the default site.
+func SetLogger(l Logger) {
+	defaultSite.SetLogger(l)
+}
+
+// WithLogger is the Option form of Site.SetLogger.
+func WithLogger(l Logger) Option {
+	return func(s *Site) { s.SetLogger(l) }
+}
+
+// log returns the configured Logger or the platform default.
+func (s *Site) log() Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return defaultLogger()
+}
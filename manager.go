@@ -5,9 +5,9 @@ import (
 )
 
 // parseRoute extracts module name and params from hash
-func parseRoute(hash string) (module string, params []string) {
+func (s *Site) parseRoute(hash string) (module string, params []string) {
 	if hash == "" || hash == "#" {
-		return config.DefaultRoute, nil // Default route
+		return s.defaultRoute(), nil // Default route
 	}
 
 	cleanHash := strings.TrimPrefix(hash, "#")
@@ -15,37 +15,50 @@ func parseRoute(hash string) (module string, params []string) {
 	cleanHash = strings.TrimPrefix(cleanHash, "/")
 
 	if cleanHash == "" {
-		return config.DefaultRoute, nil
+		return s.defaultRoute(), nil
 	}
 
 	parts := strings.Split(cleanHash, "/")
 	if len(parts) == 0 {
-		return config.DefaultRoute, nil
+		return s.defaultRoute(), nil
 	}
 
 	return parts[0], parts[1:]
 }
 
+// defaultRoute returns the module shown for an empty hash: DefaultRoute
+// when set, else "home" when registered (or nothing is), else the first
+// registered module.
+func (s *Site) defaultRoute() string {
+	if s.config.DefaultRoute != "" {
+		return s.config.DefaultRoute
+	}
+	if len(s.handler.registeredModules) == 0 || s.findModule("home") != nil {
+		return "home"
+	}
+	return s.handler.registeredModules[0].name
+}
+
 // registerModule adds a module to the site registry.
-func registerModule(m Module) {
+func (s *Site) registerModule(m Module) {
 	name := m.HandlerName()
 	exists := false
-	for _, rm := range handler.registeredModules {
+	for _, rm := range s.handler.registeredModules {
 		if rm.name == name {
 			exists = true
 			break
 		}
 	}
 	if !exists {
-		handler.registeredModules = append(handler.registeredModules, &registeredModule{
+		s.handler.registeredModules = append(s.handler.registeredModules, &registeredModule{
 			handler: m,
 			name:    name,
 		})
 	}
 }
 
-func findModule(name string) Module {
-	for _, rm := range handler.registeredModules {
+func (s *Site) findModule(name string) Module {
+	for _, rm := range s.handler.registeredModules {
 		if rm.name == name {
 			if m, ok := rm.handler.(Module); ok {
 				return m
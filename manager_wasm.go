@@ -3,21 +3,31 @@
 package site
 
 import (
+	"syscall/js"
+
 	"github.com/tinywasm/dom"
 	"github.com/tinywasm/fmt"
 )
 
-var (
+// platform is the wasm client state of a Site.
+type platform struct {
 	activeModule Module
 	cache        []Module
-)
+}
 
-// Start initializes the site by hydrating the current module.
+func (s *Site) initPlatform() {}
+
+// Start applies Site.Start to the default site.
 func Start(parentID string) error {
+	return defaultSite.Start(parentID)
+}
+
+// Start initializes the site by hydrating the current module.
+func (s *Site) Start(parentID string) error {
 	hash := dom.GetHash()
-	moduleName, params := parseRoute(hash)
+	moduleName, params := s.parseRoute(hash)
 
-	m := findModule(moduleName)
+	m := s.findModule(moduleName)
 	if m == nil {
 		return fmt.Errf("module not found: %s", moduleName)
 	}
@@ -27,9 +37,9 @@ func Start(parentID string) error {
 		p.SetParams(params)
 	}
 
-	activeModule = m
+	s.activeModule = m
 
-	if err := dom.Render(parentID, m); err != nil {
+	if err := s.render(parentID, m); err != nil {
 		return err
 	}
 
@@ -41,41 +51,48 @@ func Start(parentID string) error {
 	return nil
 }
 
-// Navigate switches to a different module based on the hash.
+// Navigate applies Site.Navigate to the default site.
 func Navigate(parentID string, hash string) error {
-	moduleName, params := parseRoute(hash)
+	return defaultSite.Navigate(parentID, hash)
+}
+
+// Navigate switches to a different module based on the hash.
+func (s *Site) Navigate(parentID string, hash string) error {
+	moduleName, params := s.parseRoute(hash)
 
-	if activeModule != nil && activeModule.HandlerName() == moduleName {
+	if s.activeModule != nil && s.activeModule.HandlerName() == moduleName {
 		// Same module, just update params
-		if p, ok := activeModule.(Parameterized); ok {
+		if p, ok := s.activeModule.(Parameterized); ok {
 			p.SetParams(params)
 		}
 
 		// Call AfterNavigateTo hook as params changed
-		if lc, ok := activeModule.(ModuleLifecycle); ok {
+		if lc, ok := s.activeModule.(ModuleLifecycle); ok {
 			lc.AfterNavigateTo()
 		}
 		return nil
 	}
 
-	target := findModule(moduleName)
+	target := s.findModule(moduleName)
 	if target == nil {
+		s.log().Warn("site: module not found", "route", hash)
 		return nil // Or handle 404
 	}
 
 	// 1. Check if current module allows navigation away
-	if activeModule != nil {
-		if lc, ok := activeModule.(ModuleLifecycle); ok {
+	if s.activeModule != nil {
+		if lc, ok := s.activeModule.(ModuleLifecycle); ok {
 			if !lc.BeforeNavigateAway() {
+				s.log().Debug("site: navigation cancelled", "handler", s.activeModule.HandlerName(), "route", hash)
 				return nil // Cancelled
 			}
 		}
-		// dom.Render handles unmount of previous content automatically
-		addToCache(activeModule)
+		// render replaces it in parentID, unmounting it
+		s.addToCache(s.activeModule)
 	}
 
 	// 2. Check cache for target
-	if cached := getFromCache(moduleName); cached != nil {
+	if cached := s.getFromCache(moduleName); cached != nil {
 		target = cached
 	}
 
@@ -85,11 +102,13 @@ func Navigate(parentID string, hash string) error {
 	}
 
 	// 4. Mount new module
-	activeModule = target
+	s.activeModule = target
 	dom.SetHash(hash)
-	if err := dom.Render(parentID, target); err != nil {
+	if err := s.render(parentID, target); err != nil {
+		s.log().Error("site: render failed", "handler", moduleName, "route", hash, "err", err)
 		return err
 	}
+	s.log().Debug("site: navigated", "handler", moduleName, "route", hash)
 
 	// 5. Call AfterNavigateTo hook
 	if lc, ok := target.(ModuleLifecycle); ok {
@@ -99,28 +118,42 @@ func Navigate(parentID string, hash string) error {
 	return nil
 }
 
-func addToCache(m Module) {
+// render renders m into parentID, which unmounts the module shown there,
+// then removes the SSR placeholder of m (see placeholderHTML) so its
+// skeleton does not stay on the page.
+func (s *Site) render(parentID string, m Module) error {
+	if err := dom.Render(parentID, m); err != nil {
+		return err
+	}
+	placeholder := js.Global().Get("document").Call("querySelector", `[data-placeholder][data-module="`+m.HandlerName()+`"]`)
+	if !placeholder.IsNull() {
+		placeholder.Call("remove")
+	}
+	return nil
+}
+
+func (s *Site) addToCache(m Module) {
 	// Simple LRU: remove oldest if full
-	for i, cm := range cache {
+	for i, cm := range s.cache {
 		if cm.HandlerName() == m.HandlerName() {
 			// Already in cache, move to front
-			cache = append(cache[:i], cache[i+1:]...)
+			s.cache = append(s.cache[:i], s.cache[i+1:]...)
 			break
 		}
 	}
 
-	if len(cache) >= config.CacheSize {
-		cache = cache[1:]
+	if len(s.cache) >= s.config.CacheSize {
+		s.cache = s.cache[1:]
 	}
-	cache = append(cache, m)
+	s.cache = append(s.cache, m)
 }
 
-func getFromCache(name string) Module {
-	for i, m := range cache {
+func (s *Site) getFromCache(name string) Module {
+	for i, m := range s.cache {
 		if m.HandlerName() == name {
 			// Move to front (latest)
-			cache = append(cache[:i], cache[i+1:]...)
-			cache = append(cache, m)
+			s.cache = append(s.cache[:i], s.cache[i+1:]...)
+			s.cache = append(s.cache, m)
 			return m
 		}
 	}
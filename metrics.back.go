@@ -0,0 +1,301 @@
+//go:build !wasm
+
+package site
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinywasm/fmt"
+)
+
+// MetricRegistry holds metrics and writes them in the Prometheus text
+// exposition format. Every Site has one (see Site.Metrics); modules add
+// their own counters to it.
+type MetricRegistry struct {
+	mu       sync.Mutex
+	families []*metricFamily
+}
+
+// CounterVec is a counter family; With selects one series.
+type CounterVec struct{ f *metricFamily }
+
+// Counter is one counter series.
+type Counter struct{ s *metricSeries }
+
+// Counter registers a counter family, or returns the one already registered
+// under name. Registering name again with another type or other label names
+// panics.
+func (m *MetricRegistry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{m.family(name, help, "counter", labels, nil)}
+}
+
+// With returns the series for the label values, in label name order.
+func (c *CounterVec) With(values ...string) *Counter {
+	return &Counter{c.f.with(values)}
+}
+
+// Inc adds 1.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add adds v; negative values are ignored, counters only go up.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.s.mu.Lock()
+	c.s.value += v
+	c.s.mu.Unlock()
+}
+
+// defaultBuckets are the latency histogram upper bounds, in seconds.
+var defaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metricFamily struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64 // histograms only
+
+	mu     sync.Mutex
+	series map[string]*metricSeries
+}
+
+type metricSeries struct {
+	values []string
+
+	mu     sync.Mutex
+	value  float64  // counter or gauge
+	counts []uint64 // histogram, per bucket (not cumulative)
+	sum    float64
+	count  uint64
+}
+
+func (m *MetricRegistry) family(name, help, kind string, labels []string, buckets []float64) *metricFamily {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range m.families {
+		if f.name == name {
+			if f.kind != kind || !slices.Equal(f.labels, labels) {
+				panic(fmt.Sprintf("site: metric %s already registered as a %s with labels [%s]", name, f.kind, strings.Join(f.labels, " ")))
+			}
+			return f
+		}
+	}
+	f := &metricFamily{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*metricSeries)}
+	m.families = append(m.families, f)
+	return f
+}
+
+func (f *metricFamily) with(values []string) *metricSeries {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("site: metric %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{values: slices.Clone(values)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (s *metricSeries) set(v float64) {
+	s.mu.Lock()
+	s.value = v
+	s.mu.Unlock()
+}
+
+func (s *metricSeries) observe(v float64, buckets []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := sort.SearchFloat64s(buckets, v); i < len(buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format
+// (version 0.0.4), families in registration order and series sorted.
+func (m *MetricRegistry) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	families := slices.Clone(m.families)
+	m.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (f *metricFamily) write(b *strings.Builder) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	series := make([]*metricSeries, len(keys))
+	for i, k := range keys {
+		series[i] = f.series[k]
+	}
+	f.mu.Unlock()
+
+	b.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	b.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+	for _, s := range series {
+		s.mu.Lock()
+		labels := labelPairs(f.labels, s.values)
+		if f.kind != "histogram" {
+			b.WriteString(f.name + braces(labels) + " " + formatFloat(s.value) + "\n")
+			s.mu.Unlock()
+			continue
+		}
+		var cumulative uint64
+		for i, le := range f.buckets {
+			cumulative += s.counts[i]
+			b.WriteString(f.name + "_bucket" + braces(append(slices.Clone(labels), `le="`+formatFloat(le)+`"`)) + " " + strconv.FormatUint(cumulative, 10) + "\n")
+		}
+		b.WriteString(f.name + "_bucket" + braces(append(slices.Clone(labels), `le="+Inf"`)) + " " + strconv.FormatUint(s.count, 10) + "\n")
+		b.WriteString(f.name + "_sum" + braces(labels) + " " + formatFloat(s.sum) + "\n")
+		b.WriteString(f.name + "_count" + braces(labels) + " " + strconv.FormatUint(s.count, 10) + "\n")
+		s.mu.Unlock()
+	}
+}
+
+func labelPairs(names, values []string) []string {
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = n + `="` + escapeLabel(values[i]) + `"`
+	}
+	return pairs
+}
+
+func braces(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// siteMetrics are the built-in metrics of a Site.
+type siteMetrics struct {
+	registry    *MetricRegistry
+	requests    *metricFamily // crudp requests by handler, action and status
+	latency     *metricFamily // crudp request duration by handler and action
+	rbacChecks  *metricFamily // access checks by resource, action and result
+	assetBytes  *metricFamily // response bytes by asset route
+	ssrDuration *metricFamily // last ssrBuild duration
+}
+
+func newSiteMetrics() *siteMetrics {
+	r := &MetricRegistry{}
+	return &siteMetrics{
+		registry:    r,
+		requests:    r.family("site_http_requests_total", "crudp requests by handler, action and status code.", "counter", []string{"handler", "action", "code"}, nil),
+		latency:     r.family("site_http_request_duration_seconds", "crudp request duration by handler and action.", "histogram", []string{"handler", "action"}, defaultBuckets),
+		rbacChecks:  r.family("site_rbac_checks_total", "RBAC access checks by resource, action and result (allow or deny).", "counter", []string{"resource", "action", "result"}, nil),
+		assetBytes:  r.family("site_asset_bytes_served_total", "Response bytes served for page and asset routes.", "counter", []string{"route"}, nil),
+		ssrDuration: r.family("site_ssr_build_duration_seconds", "Duration of the last SSR build.", "gauge", nil, nil),
+	}
+}
+
+// Metrics returns the registry served at the SetMetricsPath path.
+func (s *Site) Metrics() *MetricRegistry {
+	return s.metrics.registry
+}
+
+// Metrics applies Site.Metrics to the default site.
+func Metrics() *MetricRegistry {
+	return defaultSite.Metrics()
+}
+
+// countRBAC records one access check.
+func (m *siteMetrics) countRBAC(resource string, action byte, allowed bool) {
+	result := "deny"
+	if allowed {
+		result = "allow"
+	}
+	(&Counter{m.rbacChecks.with([]string{resource, actionName(action), result})}).Inc()
+}
+
+// metricsHandler records request metrics for crudp routes and served bytes
+// for asset routes. Each operation of a /batch request is counted under its
+// handler and action, with the status and duration of the whole batch. It
+// returns next unchanged unless SetMetricsPath was called.
+func (s *Site) metricsHandler(next http.Handler) http.Handler {
+	if s.config.MetricsPath == "" {
+		return next
+	}
+	resources := s.crudpResources()
+	m := s.metrics
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		var ops []crudpOp
+		ok := true
+		if resources[resource] {
+			if ops, r, ok = s.crudpOps(rec, r, resource); !ok {
+				ops = []crudpOp{{resource: "batch"}}
+			}
+		}
+		if ok {
+			next.ServeHTTP(rec, r)
+		}
+
+		switch {
+		case ops != nil:
+			code, elapsed := strconv.Itoa(rec.statusCode()), time.Since(start).Seconds()
+			for _, op := range ops {
+				action := "batch"
+				if op.action != 0 {
+					action = actionName(op.action)
+				}
+				(&Counter{m.requests.with([]string{op.resource, action, code})}).Inc()
+				m.latency.with([]string{op.resource, action}).observe(elapsed, m.latency.buckets)
+			}
+		case contains(assetRoutes, r.URL.Path):
+			(&Counter{m.assetBytes.with([]string{r.URL.Path})}).Add(float64(rec.bytes))
+		}
+	})
+}
+
+// metricsEndpoint serves the registry.
+func (s *Site) metricsEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	s.metrics.registry.WriteTo(w)
+}
@@ -0,0 +1,248 @@
+//go:build !wasm
+
+package site
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/site/internal/httpenc"
+)
+
+// Middleware wraps an http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Use adds middleware applied by Mount to every route the site registers
+// (assets, client, sitemap and crudp). The first middleware added is the
+// outermost: Use(Recover(), RequestID(), AccessLog()) recovers panics
+// raised by the logger too.
+func (s *Site) Use(mw ...Middleware) {
+	s.middleware = append(s.middleware, mw...)
+}
+
+// Use applies Site.Use to the default site.
+func Use(mw ...Middleware) {
+	defaultSite.Use(mw...)
+}
+
+// WithMiddleware is the Option form of Site.Use.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(s *Site) { s.Use(mw...) }
+}
+
+// chain wraps h with the site middleware, first added outermost.
+func (s *Site) chain(h http.Handler) http.Handler {
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		h = s.middleware[i](h)
+	}
+	return h
+}
+
+// Recover turns a handler panic into a 500 response and logs it with the
+// stack to the site Logger. http.ErrAbortHandler is re-raised so net/http
+// aborts the response.
+func Recover() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if v := recover(); v != nil {
+					if v == http.ErrAbortHandler {
+						panic(v)
+					}
+					loggerFrom(r.Context()).Error("site: panic serving request", "method", r.Method, "route", r.URL.Path, "panic", v, "stack", string(debug.Stack()))
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID keeps the incoming X-Request-ID header or generates one, sets it
+// on the response and stores it in the request context (see RequestIDFrom).
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 128 {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// RequestIDFrom returns the ID stored by RequestID, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLog logs one Info entry per request ("site: request") to the site
+// Logger with method, route, status, bytes, duration and request_id, the
+// last when RequestID runs before it.
+func AccessLog() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			args := []any{"method", r.Method, "route", r.URL.Path, "status", rec.statusCode(), "bytes", rec.bytes, "duration", time.Since(start)}
+			if id := RequestIDFrom(r.Context()); id != "" {
+				args = append(args, "request_id", id)
+			}
+			loggerFrom(r.Context()).Info("site: request", args...)
+		})
+	}
+}
+
+// statusRecorder records the status and body size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) statusCode() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// Gzip compresses compressible responses (text, JSON, JavaScript, XML) for
+// clients accepting gzip. Responses that already set Content-Encoding, such
+// as precompressed assets, and client.wasm pass through unchanged.
+func Gzip() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), "Accept-Encoding")
+			if r.Method == http.MethodHead || r.Header.Get("Range") != "" || !httpenc.AcceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipWriter{ResponseWriter: w}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// gzipWriter decides on the first WriteHeader or Write whether to compress.
+type gzipWriter struct {
+	http.ResponseWriter
+	zw      *gzip.Writer
+	decided bool
+}
+
+func (g *gzipWriter) WriteHeader(status int) {
+	if !g.decided {
+		g.decide(status)
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipWriter) decide(status int) {
+	g.decided = true
+	h := g.Header()
+	ct := h.Get("Content-Type")
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" || !isCompressible(ct) || strings.Contains(ct, "wasm") {
+		return
+	}
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+	g.zw = gzip.NewWriter(g.ResponseWriter)
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	if !g.decided {
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.zw != nil {
+		return g.zw.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+func (g *gzipWriter) Flush() {
+	if g.zw != nil {
+		g.zw.Flush()
+	}
+	http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+func (g *gzipWriter) Unwrap() http.ResponseWriter { return g.ResponseWriter }
+
+func (g *gzipWriter) close() {
+	if g.zw != nil {
+		g.zw.Close()
+	}
+}
+
+// addVary adds value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// SecurityHeaders sets Strict-Transport-Security (two years, subdomains
+// included; browsers ignore it over plain HTTP), X-Content-Type-Options:
+// nosniff and Referrer-Policy: strict-origin-when-cross-origin. Handlers
+// may override them.
+func SecurityHeaders() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
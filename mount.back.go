@@ -19,32 +19,46 @@ func (r *backendRegister) add(handlers ...any) error {
 	return nil
 }
 
-func init() {
-	ssr.assetRegister = &backendRegister{}
+// Mount applies Site.Mount to the default site.
+func Mount(mux *http.ServeMux) error {
+	return defaultSite.Mount(mux)
 }
 
 // Mount registers the site handlers with the provided mux and prepares assets.
-func Mount(mux *http.ServeMux) error {
-	if err := applyRBAC(); err != nil {
+func (s *Site) Mount(mux *http.ServeMux) (err error) {
+	defer func() {
+		if err != nil {
+			s.log().Error("site: mount failed", "err", err)
+		}
+	}()
+	if err := s.checkConfig(); err != nil {
 		return err
 	}
-	if rbacInitialized && getUserID == nil {
+	if err := s.applyRBAC(); err != nil {
+		return err
+	}
+	if s.rbac.initialized && s.rbac.getUserID == nil {
 		return fmt.Err("site: SetUserID must be called when using SetDB")
 	}
-	if !rbacInitialized && !config.DevMode {
+	if !s.rbac.initialized && !s.config.DevMode {
 		return fmt.Err("site: security not configured — call SetDB or set APP_ENV=development")
 	}
 
+	// Asset routes (wasm client + assetmin) share a mux so they can be
+	// served with precompressed variants when enabled
+	assets := http.NewServeMux()
+	wasmFile := s.config.OutputDir + "/client.wasm"
+
 	// Create Javascript handler
 	jsHandler := client.NewJavascriptFromArgs()
 
-	jsHandler.RegisterRoutes(mux, config.OutputDir+"/client.wasm")
+	jsHandler.RegisterRoutes(assets, wasmFile)
 
 	// Create AssetMin instance
 	am := assetmin.NewAssetMin(&assetmin.Config{
-		OutputDir:          config.OutputDir,
-		GetSSRClientInitJS: func() (string, error) { return jsHandler.GetSSRClientInitJS() },
-		DevMode:            config.DevMode,
+		OutputDir:          s.config.OutputDir,
+		GetSSRClientInitJS: s.clientInitJS(jsHandler),
+		DevMode:            s.config.DevMode,
 	})
 
 	// Register assets from modules
@@ -52,19 +66,67 @@ func Mount(mux *http.ServeMux) error {
 
 	// ssrBuild Assets (generate/minify) - MUST happen BEFORE RegisterRoutes
 	// to ensure the sprite is complete before accepting requests
-	if err := ssrBuild(am); err != nil {
+	if err := s.ssrBuild(am); err != nil {
 		return err
 	}
 
 	// Register AssetMin Routes AFTER ssrBuild to ensure sprite is complete
-	am.RegisterRoutes(mux)
+	am.RegisterRoutes(assets)
+
+	if s.sitemapEnabled() {
+		if err := s.registerSitemapRoutes(assets); err != nil {
+			return err
+		}
+	}
+
+	served, err := s.assetHandler(assets, wasmFile)
+	if err != nil {
+		return err
+	}
+	s.reportRoutes(served)
+
+	// Site routes share a mux so the middleware wraps all of them
+	routes := http.NewServeMux()
+	routes.Handle("/", served)
 
 	// Register CrudP Routes
-	handler.cp.RegisterRoutes(mux)
+	s.handler.cp.RegisterRoutes(routes)
+
+	if s.config.CSRF {
+		routes.HandleFunc("GET "+CSRFPath, csrfTokenHandler)
+	}
+	if s.config.HealthEndpoints {
+		s.registerHealth(routes, served)
+	}
+	if s.config.MetricsPath != "" {
+		routes.HandleFunc("GET "+s.config.MetricsPath, s.metricsEndpoint)
+	}
 
+	// Routes stay root-relative below the base path
+	mux.Handle(s.config.BasePath+"/", http.StripPrefix(s.config.BasePath, s.loggerHandler(s.chain(s.metricsHandler(s.csrfHandler(s.rateLimitHandler(routes)))))))
 	return nil
 }
 
+// assetHandler wraps the asset routes with the page head additions and base
+// path, precompressed variants and the CSP header, depending on config.
+func (s *Site) assetHandler(assets http.Handler, wasmFile string) (http.Handler, error) {
+	page := pageHandler(assets, s.pageEdit(s.pageHead()))
+	served := page
+	if s.config.Precompress {
+		ca := newCompressedAssets(page, map[string]string{"/client.wasm": wasmFile}, !s.config.DevMode)
+		ca.warm(assetRoutes...)
+		served = ca
+	}
+	if s.config.ContentSecurityPolicy != "" {
+		index, err := renderRoute(page, "/")
+		if err != nil {
+			return nil, err
+		}
+		served = cspHandler(served, s.pageCSP(index))
+	}
+	return served, nil
+}
+
 // Render registers the site handlers with the provided mux and prepares assets.
 // DEPRECATED: Use Mount(mux) instead.
 func Render(mux *http.ServeMux) error {
@@ -3,19 +3,36 @@
 package site
 
 import (
-	"github.com/tinywasm/fmt"
+	"syscall/js"
+
+	"github.com/tinywasm/dom"
+	"github.com/tinywasm/fetch"
 )
 
 // No init needed - asset registration is handled by SSR (mount.back.go)
 
-// Mount hydrates the initial module and blocks forever.
+// Mount applies Site.Mount to the default site.
 func Mount(parentID string) error {
+	return defaultSite.Mount(parentID)
+}
+
+// Mount hydrates the initial module and blocks forever.
+func (s *Site) Mount(parentID string) error {
+	if err := s.validate(); err != nil {
+		s.log().Error("site: mount failed", "err", err)
+		return err
+	}
+
 	// 1. Initialize Client (CrudP)
-	handler.cp.InitClient()
+	s.handler.cp.InitClient()
+	if s.config.BasePath != "" {
+		// crudp endpoints resolve below the base path
+		fetch.SetBaseURL(js.Global().Get("location").Get("origin").String() + s.config.BasePath)
+	}
 
 	// 2. Start the site module management
-	if err := Start(parentID); err != nil {
-		fmt.Printf("Error starting site: %v\n", err)
+	if err := s.Start(parentID); err != nil {
+		s.log().Error("site: start failed", "route", dom.GetHash(), "err", err)
 		return err
 	}
 
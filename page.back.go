@@ -0,0 +1,104 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tinywasm/fmt"
+)
+
+// renderRoute serves path through h and returns the response body.
+func renderRoute(h http.Handler, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	rec := newBufferedResponse()
+	h.ServeHTTP(rec, req)
+	if rec.status != http.StatusOK {
+		return nil, fmt.Err("site: rendering", path, "returned status", rec.status)
+	}
+	return rec.body.Bytes(), nil
+}
+
+// insertHead places snippet right before </head>, or at the start of the
+// document when the page has no head element.
+func insertHead(page []byte, snippet string) []byte {
+	if snippet == "" {
+		return page
+	}
+	i := bytes.Index(bytes.ToLower(page), []byte("</head>"))
+	if i < 0 {
+		return append([]byte(snippet), page...)
+	}
+	out := make([]byte, 0, len(page)+len(snippet))
+	out = append(out, page[:i]...)
+	out = append(out, snippet...)
+	return append(out, page[i:]...)
+}
+
+// pageHandler passes every HTML response of next through edit.
+// Other responses are streamed through untouched.
+func pageHandler(next http.Handler, edit func(page []byte) []byte) http.Handler {
+	if edit == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hw := &headWriter{ResponseWriter: w, edit: edit, status: http.StatusOK}
+		next.ServeHTTP(hw, r)
+		hw.flush()
+	})
+}
+
+// headWriter buffers HTML bodies so they can be edited.
+type headWriter struct {
+	http.ResponseWriter
+	edit    func(page []byte) []byte
+	status  int
+	decided bool
+	html    bool
+	buf     bytes.Buffer
+}
+
+func (w *headWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.html = strings.HasPrefix(w.Header().Get("Content-Type"), "text/html")
+	if !w.html {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	w.status = status
+	w.decide()
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	w.decide()
+	if w.html {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *headWriter) flush() {
+	w.decide()
+	if !w.html {
+		return
+	}
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+	page := w.edit(w.buf.Bytes())
+	w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(page)
+}
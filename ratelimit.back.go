@@ -0,0 +1,190 @@
+//go:build !wasm
+
+package site
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitMaxKeys bounds the buckets kept in memory; the least recently
+// used one is dropped first.
+const rateLimitMaxKeys = 10000
+
+// SetRateLimit limits every user (or client IP when anonymous) across all
+// crudp requests. Handlers implementing RateLimitProvider add their own
+// per-action limits on top. A /batch request takes one token per operation
+// it contains, from the buckets of that operation's handler and action; a
+// batch needing more tokens than a bucket's Burst is always refused with 429
+// and no Retry-After. Other requests over a limit get 429 with Retry-After
+// (default: no limit)
+func (s *Site) SetRateLimit(limit RateLimit) {
+	s.rateLimit = limit
+}
+
+// SetRateLimit applies Site.SetRateLimit to the default site.
+func SetRateLimit(limit RateLimit) {
+	defaultSite.SetRateLimit(limit)
+}
+
+// WithRateLimit is the Option form of Site.SetRateLimit.
+func WithRateLimit(limit RateLimit) Option {
+	return func(s *Site) { s.SetRateLimit(limit) }
+}
+
+// rateLimitHandler applies the site limit and the RateLimitProvider limits
+// to crudp routes. It returns next unchanged when no limit is set.
+func (s *Site) rateLimitHandler(next http.Handler) http.Handler {
+	resources := s.crudpResources()
+	perAction := make(map[string]RateLimit)
+	for _, h := range s.handler.handlers {
+		if len(handlerVerbs(h)) == 0 {
+			continue
+		}
+		name := h.(interface{ HandlerName() string }).HandlerName()
+		if p, ok := h.(RateLimitProvider); ok {
+			for _, a := range crudActions {
+				if l := p.RateLimit(a.code); l.Rate > 0 {
+					perAction[name+"/"+string(a.code)] = l
+				}
+			}
+		}
+	}
+	if s.rateLimit.Rate <= 0 && len(perAction) == 0 {
+		return next
+	}
+
+	limiter := newRateLimiter(rateLimitMaxKeys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if !resources[resource] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ops, r, ok := s.crudpOps(w, r, resource)
+		if !ok {
+			return
+		}
+		client := s.rateLimitClient(r)
+		var buckets []bucketLimit
+		for _, op := range ops {
+			if s.rateLimit.Rate > 0 {
+				buckets = append(buckets, bucketLimit{client, s.rateLimit})
+			}
+			action := op.resource + "/" + string(op.action)
+			if l, ok := perAction[action]; ok {
+				buckets = append(buckets, bucketLimit{client + " " + action, l})
+			}
+		}
+		if wait := limiter.take(buckets...); wait != 0 {
+			if wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			}
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClient keys a request by the SetUserID user, or the client IP.
+func (s *Site) rateLimitClient(r *http.Request) string {
+	if s.rbac.getUserID != nil {
+		if id := s.rbac.getUserID(r); id != "" {
+			return "user:" + id
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// bucketLimit names a bucket and its limit.
+type bucketLimit struct {
+	key   string
+	limit RateLimit
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps at most max token buckets in LRU order.
+type rateLimiter struct {
+	mu      sync.Mutex
+	max     int
+	now     func() time.Time
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+func newRateLimiter(max int) *rateLimiter {
+	return &rateLimiter{max: max, now: time.Now, buckets: make(map[string]*list.Element), lru: list.New()}
+}
+
+// take removes one token per entry from every bucket (a key listed twice
+// gives two), or none when one of them runs short; it then returns how long
+// until that bucket holds enough tokens again, or -1 when its Burst never
+// holds enough.
+func (l *rateLimiter) take(limits ...bucketLimit) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var wait time.Duration
+	denied := false
+	need := make(map[*bucket]float64, len(limits))
+	for _, bl := range limits {
+		b := l.bucket(bl.key, bl.limit, now)
+		need[b]++
+		if need[b] > float64(max(bl.limit.Burst, 1)) {
+			return -1
+		}
+		if b.tokens < need[b] {
+			denied = true
+			wait = max(wait, time.Duration((need[b]-b.tokens)/bl.limit.Rate*float64(time.Second)))
+		}
+	}
+	if denied {
+		return max(wait, time.Nanosecond)
+	}
+	for b, n := range need {
+		b.tokens -= n
+	}
+	return 0
+}
+
+// bucket returns the refilled bucket for key, creating a full one.
+func (l *rateLimiter) bucket(key string, limit RateLimit, now time.Time) *bucket {
+	burst := float64(max(limit.Burst, 1))
+	if e, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(e)
+		b := e.Value.(*bucket)
+		b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+		b.last = now
+		return b
+	}
+	if l.lru.Len() >= l.max {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*bucket).key)
+	}
+	b := &bucket{key: key, tokens: burst, last: now}
+	l.buckets[key] = l.lru.PushFront(b)
+	return b
+}
+
+func (l *rateLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
@@ -0,0 +1,14 @@
+package site
+
+// RateLimit is a token bucket: Burst requests at once, refilled at Rate
+// requests per second. A zero Rate means no limit; Burst defaults to 1.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitProvider is an optional interface for CRUD handlers that limit
+// each user (or client IP when anonymous) per action ('c', 'r', 'u', 'd').
+type RateLimitProvider interface {
+	RateLimit(action byte) RateLimit
+}
@@ -19,35 +19,58 @@ type roleSpec struct {
 	description string
 }
 
-var (
-	dbExecutor      DBExecutor
+// rbacState is the access control setup of a Site. The tinywasm/rbac store
+// itself is process-wide: sites calling SetDB share roles and permissions.
+type rbacState struct {
+	db              DBExecutor
 	getUserID       func(data ...any) string
 	pendingRoles    []roleSpec
 	pendingHandlers []any
-	rbacInitialized bool
-)
+	initialized     bool
+	ping            dbPinger // /readyz database check
+}
 
-// SetDB sets the database executor. rbac initialization is deferred to Serve/Mount.
+// SetDB applies Site.SetDB to the default site.
 func SetDB(exec DBExecutor) {
-	dbExecutor = exec
+	defaultSite.SetDB(exec)
+}
+
+// SetDB sets the database executor. rbac initialization is deferred to Serve/Mount.
+func (s *Site) SetDB(exec DBExecutor) {
+	s.rbac.db = exec
+}
+
+// SetUserID applies Site.SetUserID to the default site.
+func SetUserID(fn func(data ...any) string) {
+	defaultSite.SetUserID(fn)
 }
 
 // SetUserID configures how to extract the current user's ID from request data.
 // Required when SetDB has been called. Validated at Mount time.
-func SetUserID(fn func(data ...any) string) {
-	getUserID = fn
+func (s *Site) SetUserID(fn func(data ...any) string) {
+	s.rbac.getUserID = fn
+}
+
+// CreateRole applies Site.CreateRole to the default site.
+func CreateRole(code byte, name, description string) {
+	defaultSite.CreateRole(code, name, description)
 }
 
 // CreateRole queues a role for creation at Serve/Mount time.
 // Idempotent: safe to call on every startup (ON CONFLICT (code) DO NOTHING).
-func CreateRole(code byte, name, description string) {
-	pendingRoles = append(pendingRoles, roleSpec{code, name, description})
+func (s *Site) CreateRole(code byte, name, description string) {
+	s.rbac.pendingRoles = append(s.rbac.pendingRoles, roleSpec{code, name, description})
+}
+
+// AssignRole applies Site.AssignRole to the default site.
+func AssignRole(userID string, roleCode byte) error {
+	return defaultSite.AssignRole(userID, roleCode)
 }
 
 // AssignRole grants a role (identified by code) to a user.
 // Typically called in the login handler after authentication.
-func AssignRole(userID string, roleCode byte) error {
-	if !rbacInitialized {
+func (s *Site) AssignRole(userID string, roleCode byte) error {
+	if !s.rbac.initialized {
 		return fmt.Err("site: Serve must be called before AssignRole")
 	}
 	role, err := rbac.GetRoleByCode(roleCode)
@@ -57,9 +80,14 @@ func AssignRole(userID string, roleCode byte) error {
 	return rbac.AssignRole(userID, role.ID)
 }
 
-// RevokeRole removes a role (identified by code) from a user.
+// RevokeRole applies Site.RevokeRole to the default site.
 func RevokeRole(userID string, roleCode byte) error {
-	if !rbacInitialized {
+	return defaultSite.RevokeRole(userID, roleCode)
+}
+
+// RevokeRole removes a role (identified by code) from a user.
+func (s *Site) RevokeRole(userID string, roleCode byte) error {
+	if !s.rbac.initialized {
 		return fmt.Err("site: Serve must be called before RevokeRole")
 	}
 	role, err := rbac.GetRoleByCode(roleCode)
@@ -69,42 +97,55 @@ func RevokeRole(userID string, roleCode byte) error {
 	return rbac.RevokeRole(userID, role.ID)
 }
 
-// GetUserRoleCodes returns the role codes assigned to a user (e.g., []byte{'a', 'e'}).
+// GetUserRoleCodes applies Site.GetUserRoleCodes to the default site.
 func GetUserRoleCodes(userID string) ([]byte, error) {
-	if !rbacInitialized {
+	return defaultSite.GetUserRoleCodes(userID)
+}
+
+// GetUserRoleCodes returns the role codes assigned to a user (e.g., []byte{'a', 'e'}).
+func (s *Site) GetUserRoleCodes(userID string) ([]byte, error) {
+	if !s.rbac.initialized {
 		return nil, fmt.Err("site: Serve must be called before GetUserRoleCodes")
 	}
 	return rbac.GetUserRoleCodes(userID)
 }
 
 // registerRBAC queues handlers for permission seeding. Applied by applyRBAC at Mount time.
-func registerRBAC(handlers ...any) error {
-	pendingHandlers = append(pendingHandlers, handlers...)
+func (s *Site) registerRBAC(handlers ...any) error {
+	s.rbac.pendingHandlers = append(s.rbac.pendingHandlers, handlers...)
 	return nil
 }
 
 // applyRBAC initializes rbac and seeds roles and permissions from queued state.
 // Called once at Mount time. No-op when SetDB was not called (dev mode).
-func applyRBAC() error {
-	if dbExecutor == nil {
+func (s *Site) applyRBAC() error {
+	if s.rbac.db == nil {
 		return nil
 	}
-	if err := rbac.Init(dbExecutor); err != nil {
+	if err := rbac.Init(s.rbac.db); err != nil {
 		return err
 	}
-	rbacInitialized = true
-	handler.cp.SetAccessCheck(func(resource string, action byte, data ...any) bool {
-		if getUserID == nil {
+	s.rbac.initialized = true
+	s.handler.cp.SetAccessCheck(func(resource string, action byte, data ...any) bool {
+		if s.rbac.getUserID == nil {
 			return false
 		}
-		userID := getUserID(data...)
+		userID := s.rbac.getUserID(data...)
 		if userID == "" {
+			s.metrics.countRBAC(resource, action, false)
+			s.log().Debug("site: access denied", "resource", resource, "action", actionName(action), "reason", "anonymous")
 			return false
 		}
-		ok, _ := rbac.HasPermission(userID, resource, action)
+		ok, err := rbac.HasPermission(userID, resource, action)
+		s.metrics.countRBAC(resource, action, ok)
+		if err != nil {
+			s.log().Error("site: permission check failed", "user", userID, "resource", resource, "action", actionName(action), "err", err)
+		} else if !ok {
+			s.log().Warn("site: access denied", "user", userID, "resource", resource, "action", actionName(action))
+		}
 		return ok
 	})
-	for _, r := range pendingRoles {
+	for _, r := range s.rbac.pendingRoles {
 		u, err := unixid.NewUnixID()
 		if err != nil {
 			return err
@@ -113,8 +154,8 @@ func applyRBAC() error {
 			return err
 		}
 	}
-	if len(pendingHandlers) > 0 {
-		return rbac.Register(pendingHandlers...)
+	if len(s.rbac.pendingHandlers) > 0 {
+		return rbac.Register(s.rbac.pendingHandlers...)
 	}
 	return nil
 }
@@ -6,6 +6,12 @@ import (
 
 // RegisterHandlers registers all handlers with site and crudp
 func RegisterHandlers(handlers ...any) error {
+	return defaultSite.RegisterHandlers(handlers...)
+}
+
+// RegisterHandlers registers all handlers with the site and its crudp instance.
+// Handlers without a name and duplicate names are reported by Mount.
+func (s *Site) RegisterHandlers(handlers ...any) error {
 
 	if len(handlers) == 0 {
 		return fmt.Err("site: no handlers provided")
@@ -18,34 +24,36 @@ func RegisterHandlers(handlers ...any) error {
 		}
 
 		if name == "" {
+			s.handler.unnamed = append(s.handler.unnamed, h)
 			continue
 		}
+		s.handler.handlers = append(s.handler.handlers, h)
 
 		// Register as module if it implements Module interface
 		if m, ok := h.(Module); ok {
-			registerModule(m)
+			s.registerModule(m)
 		}
 
 	}
 
-	if err := handler.cp.RegisterHandlers(handlers...); err != nil {
-		fmt.Println("site: crudp registration error:", err)
+	if err := s.handler.cp.RegisterHandlers(handlers...); err != nil {
+		s.log().Error("site: crudp registration failed", "phase", "crudp", "err", err)
 		return err
 	}
 	// Seed rbac permissions (backend only, no-op on wasm)
-	if err := registerRBAC(handlers...); err != nil {
-		fmt.Println("site: rbac registration error:", err)
+	if err := s.registerRBAC(handlers...); err != nil {
+		s.log().Error("site: rbac registration failed", "phase", "rbac", "err", err)
 		return err
 	}
 	// Register assets (SSR only)
-	if err := registerAssets(handlers...); err != nil {
-		fmt.Println("site: asset registration error:", err)
+	if err := s.registerAssets(handlers...); err != nil {
+		s.log().Error("site: asset registration failed", "phase", "assets", "err", err)
 		return err
 	}
 	return nil
 }
 
 // getModules returns all registered modules
-func getModules() []*registeredModule {
-	return handler.registeredModules
+func (s *Site) getModules() []*registeredModule {
+	return s.handler.registeredModules
 }
@@ -2,9 +2,9 @@
 
 package site
 
-func registerAssets(handlers ...any) error {
-	if ssr.assetRegister == nil {
+func (s *Site) registerAssets(handlers ...any) error {
+	if s.ssr.assetRegister == nil {
 		return nil
 	}
-	return ssr.assetRegister.add(handlers...)
+	return s.ssr.assetRegister.add(handlers...)
 }
@@ -2,10 +2,10 @@
 
 package site
 
-func registerAssets(handlers ...any) error {
+func (s *Site) registerAssets(handlers ...any) error {
 	return nil
 }
 
-func registerRBAC(handlers ...any) error {
+func (s *Site) registerRBAC(handlers ...any) error {
 	return nil
 }
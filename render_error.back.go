@@ -0,0 +1,80 @@
+//go:build !wasm
+
+package site
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/fmt"
+)
+
+// Render phases reported in RenderError.Phase.
+const (
+	PhaseDiscovery      = "discovery"
+	PhaseCSS            = "css"
+	PhaseJS             = "js"
+	PhaseIcons          = "icons"
+	PhaseHTML           = "html"
+	PhasePlaceholder    = "placeholder"
+	PhaseStructuredData = "structured-data"
+)
+
+// RenderError describes a handler that panicked or failed during ssrBuild.
+type RenderError struct {
+	Handler string // HandlerName of the owning module
+	Type    string // Go type of the failing component, e.g. "*user.User"
+	Phase   string // one of the Phase* constants
+	Err     error
+}
+
+func (e *RenderError) Error() string {
+	return e.Handler + " (" + e.Type + ") " + e.Phase + ": " + e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// BuildError aggregates every RenderError of one ssrBuild run.
+// It is returned by Mount, Serve and BuildStatic unless
+// SetContinueOnRenderError(true) was called.
+type BuildError struct {
+	Failures []*RenderError
+}
+
+func (e *BuildError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("site: " + strconv.Itoa(len(e.Failures)) + " render failure(s):")
+	for _, f := range e.Failures {
+		sb.WriteString("\n  - " + f.Error())
+	}
+	return sb.String()
+}
+
+// guard runs fn and converts a panic or returned error into a RenderError.
+// The time spent is attributed to owner in the build report.
+func (s *buildStats) guard(owner string, c any, phase string, fn func() error) (rerr *RenderError) {
+	start := time.Now()
+	defer func() {
+		s.track(owner, time.Since(start))
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Err(fmt.Sprintf("panic: %v", r))
+			}
+			rerr = &RenderError{Handler: owner, Type: typeName(c), Phase: phase, Err: err}
+		}
+	}()
+	if err := fn(); err != nil {
+		return &RenderError{Handler: owner, Type: typeName(c), Phase: phase, Err: err}
+	}
+	return nil
+}
+
+// errorModuleHTML substitutes a module whose HTML could not be rendered.
+func errorModuleHTML(m *registeredModule) string {
+	name := html.EscapeString(m.name)
+	return `<section id="` + name + `" data-module="` + name + `" data-render-error role="alert">` +
+		`<p>This section is temporarily unavailable.</p></section>`
+}
@@ -0,0 +1,185 @@
+//go:build !wasm
+
+package site
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const buildReportFlag = "--ssr-report"
+
+// BuildReport describes what the last Mount or BuildStatic produced.
+type BuildReport struct {
+	OutputDir  string         `json:"output_dir,omitempty"` // empty for Mount (in-memory assets)
+	BasePath   string         `json:"base_path,omitempty"`  // SetBasePath, URLs in the output start with it
+	DurationMS float64        `json:"duration_ms"`          // ssrBuild duration
+	CSSBytes   int            `json:"css_bytes"`            // inline CSS bundle
+	JSBytes    int            `json:"js_bytes"`             // inline JS bundle
+	Modules    []ModuleReport `json:"modules"`
+	Files      []FileReport   `json:"files"`
+	Failures   []string       `json:"failures,omitempty"`
+}
+
+// ModuleReport describes the contribution of one registered module.
+type ModuleReport struct {
+	Name       string   `json:"name"`
+	Title      string   `json:"title"`
+	Mode       string   `json:"mode"`       // "ssr" or "spa"
+	HTMLBytes  int      `json:"html_bytes"` // rendered HTML or placeholder
+	CSSBytes   int      `json:"css_bytes"`
+	JSBytes    int      `json:"js_bytes"`
+	Icons      []string `json:"icons,omitempty"`
+	Files      []string `json:"files"`
+	DurationMS float64  `json:"duration_ms"`
+}
+
+// FileReport is an output file (BuildStatic) or served asset route (Mount).
+type FileReport struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// LastBuildReport applies Site.LastBuildReport to the default site.
+func LastBuildReport() *BuildReport {
+	return defaultSite.LastBuildReport()
+}
+
+// LastBuildReport returns the report of the most recent Mount or BuildStatic,
+// or nil before the first build.
+func (s *Site) LastBuildReport() *BuildReport {
+	return s.lastReport
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *BuildReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteTable writes a human-readable summary of the report.
+func (r *BuildReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	io.WriteString(tw, "MODULE\tMODE\tHTML\tCSS\tJS\tICONS\tTIME\n")
+	for _, m := range r.Modules {
+		io.WriteString(tw, m.Name+"\t"+m.Mode+"\t"+
+			strconv.Itoa(m.HTMLBytes)+"\t"+strconv.Itoa(m.CSSBytes)+"\t"+strconv.Itoa(m.JSBytes)+"\t"+
+			strconv.Itoa(len(m.Icons))+"\t"+formatMS(m.DurationMS)+"\n")
+	}
+	io.WriteString(tw, "\nFILE\tBYTES\n")
+	for _, f := range r.Files {
+		io.WriteString(tw, f.Path+"\t"+strconv.FormatInt(f.Bytes, 10)+"\n")
+	}
+	io.WriteString(tw, "\ntotal ssrBuild\t"+formatMS(r.DurationMS)+"\n")
+	return tw.Flush()
+}
+
+func formatMS(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', 2, 64) + "ms"
+}
+
+// buildStats accumulates the report while ssrBuild runs.
+type buildStats struct {
+	report *BuildReport
+	byName map[string]*ModuleReport
+	order  []string
+}
+
+func newBuildStats() *buildStats {
+	return &buildStats{report: &BuildReport{}, byName: make(map[string]*ModuleReport)}
+}
+
+// module returns the entry for a handler name, creating it on first use.
+func (s *buildStats) module(name string) *ModuleReport {
+	if m, ok := s.byName[name]; ok {
+		return m
+	}
+	m := &ModuleReport{Name: name, Files: []string{}}
+	s.byName[name] = m
+	s.order = append(s.order, name)
+	return m
+}
+
+// track attributes time spent rendering to a handler.
+func (s *buildStats) track(name string, d time.Duration) {
+	if s == nil || name == "" {
+		return
+	}
+	s.module(name).DurationMS += float64(d.Microseconds()) / 1000
+}
+
+// finish freezes the collected entries into the report.
+func (s *buildStats) finish(d time.Duration, failures []*RenderError) *BuildReport {
+	r := s.report
+	r.DurationMS = float64(d.Microseconds()) / 1000
+	r.Modules = make([]ModuleReport, 0, len(s.order))
+	for _, name := range s.order {
+		m := s.byName[name]
+		sort.Strings(m.Icons)
+		r.Modules = append(r.Modules, *m)
+	}
+	for _, f := range failures {
+		r.Failures = append(r.Failures, f.Error())
+	}
+	return r
+}
+
+// reportDir lists the files written by BuildStatic.
+func reportDir(r *BuildReport, dir string) error {
+	r.OutputDir = dir
+	r.Files = nil
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		r.Files = append(r.Files, FileReport{Path: filepath.ToSlash(rel), Bytes: info.Size()})
+		return nil
+	})
+}
+
+// reportRoutes lists the asset routes served by Mount with their sizes.
+func (s *Site) reportRoutes(h http.Handler) {
+	r := s.lastReport
+	r.Files = nil
+	for _, route := range assetRoutes {
+		if (route == "/sitemap.xml" || route == "/robots.txt") && !s.sitemapEnabled() {
+			continue
+		}
+		if body, err := renderRoute(h, route); err == nil {
+			name := strings.TrimPrefix(route, "/")
+			if name == "" {
+				name = "index.html"
+			}
+			r.Files = append(r.Files, FileReport{Path: name, Bytes: int64(len(body))})
+		}
+	}
+}
+
+// writeReportFile writes the report as JSON to path.
+func writeReportFile(r *BuildReport, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteJSON(f)
+}
@@ -0,0 +1,190 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/tinywasm/assetmin"
+	"github.com/tinywasm/binary"
+	"github.com/tinywasm/crudp"
+)
+
+const routesFlag = "--ssr-routes"
+
+// RouteInfo describes one registered handler.
+type RouteInfo struct {
+	Name     string            `json:"name"`            // HandlerName: route (#name) and crudp resource
+	Title    string            `json:"title,omitempty"` // ModuleTitle, modules only
+	Mode     string            `json:"mode"`            // "ssr", "spa" or "api" (not a module)
+	Verbs    []string          `json:"verbs"`           // implemented CRUD actions: create, read, update, delete
+	Roles    map[string]string `json:"roles,omitempty"` // verb -> AllowedRoles codes ("*" = public)
+	CSSBytes int               `json:"css_bytes"`
+	JSBytes  int               `json:"js_bytes"`
+	Icons    []string          `json:"icons,omitempty"`
+}
+
+// crudActions pairs each CRUD action code with its name.
+var crudActions = []struct {
+	code byte
+	name string
+}{{'c', "create"}, {'r', "read"}, {'u', "update"}, {'d', "delete"}}
+
+// actionName names a CRUD action code for metric labels and logs.
+func actionName(code byte) string {
+	for _, a := range crudActions {
+		if a.code == code {
+			return a.name
+		}
+	}
+	return "unknown"
+}
+
+// Routes applies Site.Routes to the default site.
+func Routes() []RouteInfo {
+	return defaultSite.Routes()
+}
+
+// Routes lists every named handler passed to RegisterHandlers, in
+// registration order. Asset sizes come from the last Mount or BuildStatic
+// and are zero before the first build.
+func (s *Site) Routes() []RouteInfo {
+	assets := make(map[string]ModuleReport)
+	if s.lastReport != nil {
+		for _, m := range s.lastReport.Modules {
+			assets[m.Name] = m
+		}
+	}
+
+	routes := make([]RouteInfo, 0, len(s.handler.handlers))
+	for _, h := range s.handler.handlers {
+		name := h.(interface{ HandlerName() string }).HandlerName()
+		r := RouteInfo{Name: name, Mode: "api", Verbs: handlerVerbs(h)}
+		m, module := h.(Module)
+		if module {
+			r.Title = m.ModuleTitle()
+			r.Mode = "spa"
+			if isPublicReadable(h) {
+				r.Mode = "ssr"
+			}
+		}
+		// Roles of the implemented verbs, plus read for modules (it decides SSR vs SPA)
+		if al, ok := h.(accessLevel); ok {
+			for _, a := range crudActions {
+				if !contains(r.Verbs, a.name) && !(module && a.code == 'r') {
+					continue
+				}
+				if roles := al.AllowedRoles(a.code); len(roles) > 0 {
+					if r.Roles == nil {
+						r.Roles = make(map[string]string)
+					}
+					r.Roles[a.name] = string(roles)
+				}
+			}
+		}
+		if a, ok := assets[name]; ok {
+			r.CSSBytes, r.JSBytes, r.Icons = a.CSSBytes, a.JSBytes, a.Icons
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// handlerVerbs returns the CRUD actions h implements, in crudActions order.
+func handlerVerbs(h any) []string {
+	verbs := []string{}
+	for _, a := range crudActions {
+		var ok bool
+		switch a.code {
+		case 'c':
+			_, ok = h.(crudp.Creator)
+		case 'r':
+			_, ok = h.(crudp.Reader)
+		case 'u':
+			_, ok = h.(crudp.Updater)
+		case 'd':
+			_, ok = h.(crudp.Deleter)
+		}
+		if ok {
+			verbs = append(verbs, a.name)
+		}
+	}
+	return verbs
+}
+
+// crudpResources returns the first path segments crudp serves: "batch" and
+// the name of every handler implementing a CRUD action.
+func (s *Site) crudpResources() map[string]bool {
+	resources := map[string]bool{"batch": true}
+	for _, h := range s.handler.handlers {
+		if len(handlerVerbs(h)) > 0 {
+			resources[h.(interface{ HandlerName() string }).HandlerName()] = true
+		}
+	}
+	return resources
+}
+
+// crudpOp is one crudp operation: the handler name and the action code.
+type crudpOp struct {
+	resource string
+	action   byte
+}
+
+type crudpOpsKey struct{}
+
+// maxBatchBytes bounds the /batch body crudpOps reads; crudp sets no limit.
+const maxBatchBytes = 1 << 20
+
+// crudpOps returns the operations of a request to the crudp route resource:
+// one for /resource, one per packet for /batch. The /batch body is decoded
+// once, restored for crudp and kept in the returned request's context; a
+// body that does not decode is one operation with action 0. A body over
+// maxBatchBytes answers 413 and returns false.
+func (s *Site) crudpOps(w http.ResponseWriter, r *http.Request, resource string) ([]crudpOp, *http.Request, bool) {
+	if resource != "batch" {
+		return []crudpOp{{resource, crudp.MethodToAction(r.Method)}}, r, true
+	}
+	if ops, ok := r.Context().Value(crudpOpsKey{}).([]crudpOp); ok {
+		return ops, r, true
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBytes))
+	r.Body.Close()
+	if _, tooLarge := err.(*http.MaxBytesError); tooLarge {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return nil, r, false
+	}
+	ops := []crudpOp{{resource: "batch"}}
+	var req crudp.BatchRequest
+	if binary.Decode(body, &req) == nil && len(req.Packets) > 0 {
+		ops = ops[:0]
+		for _, p := range req.Packets {
+			name := s.handler.cp.GetHandlerName(p.HandlerID)
+			if name == "" {
+				name = "unknown"
+			}
+			ops = append(ops, crudpOp{name, p.Action})
+		}
+	}
+	r = r.WithContext(context.WithValue(r.Context(), crudpOpsKey{}, ops))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return ops, r, true
+}
+
+// writeRoutesFile runs an in-memory ssrBuild so asset sizes are known and
+// writes s.Routes() to path as JSON.
+func (s *Site) writeRoutesFile(path string) error {
+	am := assetmin.NewAssetMin(&assetmin.Config{OutputDir: s.config.OutputDir})
+	if err := s.ssrBuild(am); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeJSON(f, s.Routes())
+}
@@ -2,14 +2,174 @@
 
 package site
 
-import "net/http"
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownHook is an optional interface for handlers that flush work when
+// the server stops. ServeContext calls it after in-flight requests drained.
+type ShutdownHook interface {
+	OnShutdown(ctx context.Context) error
+}
+
+// ServeOption configures ServeContext.
+type ServeOption func(*serveConfig)
+
+type serveConfig struct {
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	certFile, keyFile string
+}
+
+func defaultServeConfig() serveConfig {
+	return serveConfig{
+		readHeaderTimeout: 10 * time.Second,
+		readTimeout:       30 * time.Second,
+		writeTimeout:      60 * time.Second,
+		idleTimeout:       120 * time.Second,
+		shutdownTimeout:   30 * time.Second,
+	}
+}
+
+// WithReadTimeout limits reading a request, headers and body (default: 30s).
+// The header limit is the smaller of d and 10s.
+func WithReadTimeout(d time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.readTimeout = d
+		c.readHeaderTimeout = min(d, c.readHeaderTimeout)
+	}
+}
+
+// WithWriteTimeout limits writing a response (default: 60s).
+func WithWriteTimeout(d time.Duration) ServeOption {
+	return func(c *serveConfig) { c.writeTimeout = d }
+}
+
+// WithIdleTimeout limits how long keep-alive connections wait for the next
+// request (default: 120s).
+func WithIdleTimeout(d time.Duration) ServeOption {
+	return func(c *serveConfig) { c.idleTimeout = d }
+}
+
+// WithShutdownTimeout limits draining in-flight requests and running the
+// shutdown hooks (default: 30s).
+func WithShutdownTimeout(d time.Duration) ServeOption {
+	return func(c *serveConfig) { c.shutdownTimeout = d }
+}
+
+// WithTLS serves HTTPS with the given certificate and key files.
+func WithTLS(certFile, keyFile string) ServeOption {
+	return func(c *serveConfig) { c.certFile, c.keyFile = certFile, keyFile }
+}
+
+// Serve applies Site.Serve to the default site.
+func Serve(addr string) error {
+	return defaultSite.Serve(addr)
+}
 
 // Serve starts the server on the given address (one-liner helper).
 // It creates a new ServeMux, mounts the site, and listens on the address.
-func Serve(addr string) error {
+// SIGINT and SIGTERM shut it down gracefully, as in ServeContext.
+func (s *Site) Serve(addr string) error {
+	return s.ServeContext(context.Background(), addr)
+}
+
+// ServeContext applies Site.ServeContext to the default site.
+func ServeContext(ctx context.Context, addr string, opts ...ServeOption) error {
+	return defaultSite.ServeContext(ctx, addr, opts...)
+}
+
+// ServeContext mounts the site on a new ServeMux and serves it on addr
+// until ctx is cancelled or the process receives SIGINT or SIGTERM. It then
+// stops accepting connections, waits for in-flight requests (crudp calls
+// included) and runs the shutdown hooks. A graceful stop returns nil.
+func (s *Site) ServeContext(ctx context.Context, addr string, opts ...ServeOption) error {
 	mux := http.NewServeMux()
-	if err := Mount(mux); err != nil {
+	if err := s.Mount(mux); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.serveListener(ctx, ln, mux, opts...)
+}
+
+// OnShutdown applies Site.OnShutdown to the default site.
+func OnShutdown(fn func(ctx context.Context) error) {
+	defaultSite.OnShutdown(fn)
+}
+
+// OnShutdown adds a hook run by ServeContext after in-flight requests
+// drained. Hooks run in the order they were added, after the OnShutdown
+// methods of registered handlers (see ShutdownHook).
+func (s *Site) OnShutdown(fn func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, fn)
+}
+
+// serveListener serves h on ln until ctx is done or a stop signal arrives,
+// then shuts down gracefully.
+func (s *Site) serveListener(ctx context.Context, ln net.Listener, h http.Handler, opts ...ServeOption) error {
+	cfg := defaultServeConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: cfg.readHeaderTimeout,
+		ReadTimeout:       cfg.readTimeout,
+		WriteTimeout:      cfg.writeTimeout,
+		IdleTimeout:       cfg.idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		if cfg.certFile != "" {
+			served <- srv.ServeTLS(ln, cfg.certFile, cfg.keyFile)
+			return
+		}
+		served <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-served:
 		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return errors.Join(err, s.runShutdownHooks(shutdownCtx))
+}
+
+// runShutdownHooks calls every ShutdownHook handler, then the OnShutdown
+// functions, and returns their errors joined.
+func (s *Site) runShutdownHooks(ctx context.Context) error {
+	var errs []error
+	for _, h := range s.handler.handlers {
+		if hook, ok := h.(ShutdownHook); ok {
+			errs = append(errs, hook.OnShutdown(ctx))
+		}
+	}
+	for _, fn := range s.shutdownHooks {
+		errs = append(errs, fn(ctx))
 	}
-	return http.ListenAndServe(addr, mux)
+	return errors.Join(errs...)
 }
@@ -3,6 +3,8 @@
 package site
 
 import (
+	"context"
+	"net/http"
 	"os"
 	"reflect"
 	"strings"
@@ -14,22 +16,39 @@ import (
 type ssrState struct {
 	assetRegister     assetRegister
 	componentRegistry *ssrComponentRegistry
+	head              []string    // snippets for the page <head>, rebuilt by ssrBuild
+	stats             *buildStats // report of the running ssrBuild
 }
 
-var ssr = &ssrState{
-	componentRegistry: &ssrComponentRegistry{},
+// platform is the server-side state of a Site.
+type platform struct {
+	ssr        *ssrState
+	rbac       rbacState
+	lastReport *BuildReport // report of the last ssrBuild
+
+	shutdownHooks []func(ctx context.Context) error
+	middleware    []Middleware
+	csrfExempt    func(r *http.Request) bool
+	rateLimit     RateLimit
+	metrics       *siteMetrics
+	configLoad    []string // problems of the last LoadConfig
 }
 
-func init() {
+// initPlatform prepares the SSR state and applies APP_ENV=development and
+// the -dev argument.
+func (s *Site) initPlatform() {
+	s.ssr = &ssrState{
+		assetRegister:     &backendRegister{},
+		componentRegistry: &ssrComponentRegistry{},
+	}
+	s.metrics = newSiteMetrics()
 	env := os.Getenv("APP_ENV")
 	if env == "development" || env == "dev" {
-		config.DevMode = true
-		handler.DevMode = true
+		s.SetDevMode(true)
 	}
 	for _, arg := range os.Args {
 		if arg == "-dev" {
-			config.DevMode = true
-			handler.DevMode = true
+			s.SetDevMode(true)
 			break
 		}
 	}
@@ -38,11 +57,14 @@ func init() {
 type ssrComponentRegistry struct {
 	// registered tracks components by type to avoid duplicate asset collection
 	registered map[reflect.Type]dom.Component
+	// owners maps each component type to the handler that contributed it
+	owners map[reflect.Type]string
 }
 
-func (r *ssrComponentRegistry) register(c dom.Component) {
+func (r *ssrComponentRegistry) register(c dom.Component, owner string) {
 	if r.registered == nil {
 		r.registered = make(map[reflect.Type]dom.Component)
+		r.owners = make(map[reflect.Type]string)
 	}
 	if c == nil {
 		return
@@ -50,48 +72,70 @@ func (r *ssrComponentRegistry) register(c dom.Component) {
 	t := reflect.TypeOf(c)
 	if _, exists := r.registered[t]; !exists {
 		r.registered[t] = c
+		r.owners[t] = owner
 	}
 }
 
 // collectCSS generates a single CSS string from all registered components.
-func (r *ssrComponentRegistry) collectCSS() string {
+// Sizes and render time are recorded in stats.
+func (r *ssrComponentRegistry) collectCSS(stats *buildStats) (string, []*RenderError) {
 	var sb strings.Builder
-	for _, c := range r.registered {
+	var failures []*RenderError
+	for t, c := range r.registered {
 		if prov, ok := c.(dom.CSSProvider); ok {
-			css := prov.RenderCSS()
-			if css != "" {
-				sb.WriteString(css)
-				sb.WriteString("\n")
+			if f := stats.guard(r.owners[t], c, PhaseCSS, func() error {
+				if css := prov.RenderCSS(); css != "" {
+					stats.module(r.owners[t]).CSSBytes += len(css)
+					sb.WriteString(css)
+					sb.WriteString("\n")
+				}
+				return nil
+			}); f != nil {
+				failures = append(failures, f)
 			}
 		}
 	}
-	return sb.String()
+	return sb.String(), failures
 }
 
 // collectIcons extracts all icons from registered components.
-func (r *ssrComponentRegistry) collectIcons() map[string]string {
+func (r *ssrComponentRegistry) collectIcons(stats *buildStats) (map[string]string, []*RenderError) {
 	icons := make(map[string]string)
-	for _, c := range r.registered {
+	var failures []*RenderError
+	for t, c := range r.registered {
 		if prov, ok := c.(dom.IconSvgProvider); ok {
-			for id, svg := range prov.IconSvg() {
-				icons[id] = svg
+			if f := stats.guard(r.owners[t], c, PhaseIcons, func() error {
+				for id, svg := range prov.IconSvg() {
+					icons[id] = svg
+					m := stats.module(r.owners[t])
+					m.Icons = append(m.Icons, id)
+				}
+				return nil
+			}); f != nil {
+				failures = append(failures, f)
 			}
 		}
 	}
-	return icons
+	return icons, failures
 }
 
 // collectJS generates a single JS string from all registered components.
-func (r *ssrComponentRegistry) collectJS() string {
+func (r *ssrComponentRegistry) collectJS(stats *buildStats) (string, []*RenderError) {
 	var sb strings.Builder
-	for _, c := range r.registered {
+	var failures []*RenderError
+	for t, c := range r.registered {
 		if prov, ok := c.(dom.JSProvider); ok {
-			js := prov.RenderJS()
-			if js != "" {
-				sb.WriteString(js)
-				sb.WriteString("\n")
+			if f := stats.guard(r.owners[t], c, PhaseJS, func() error {
+				if js := prov.RenderJS(); js != "" {
+					stats.module(r.owners[t]).JSBytes += len(js)
+					sb.WriteString(js)
+					sb.WriteString("\n")
+				}
+				return nil
+			}); f != nil {
+				failures = append(failures, f)
 			}
 		}
 	}
-	return sb.String()
+	return sb.String(), failures
 }
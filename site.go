@@ -13,6 +13,8 @@ type siteHandler struct {
 	DevMode           bool
 	cp                *crudp.CrudP
 	registeredModules []*registeredModule
+	handlers          []any // every named handler, in registration order
+	unnamed           []any // handlers without a HandlerName, reported at Mount
 }
 
 // registeredModule wraps a handler for site registration
@@ -21,13 +23,35 @@ type registeredModule struct {
 	name    string
 }
 
-var (
-	// Singleton instances
-	handler = &siteHandler{
-		cp: crudp.New(),
+// Site holds the configuration, registered handlers and build state of one
+// site. The package-level functions act on a default Site; New creates
+// independent ones, e.g. a public and an admin site on different muxes.
+type Site struct {
+	config   *Config
+	handler  *siteHandler
+	logger   Logger // nil: platform default
+	platform        // server (SSR, rbac) or wasm client (navigation) state
+}
+
+// Option configures a Site created by New.
+type Option func(*Site)
+
+// New returns a Site with the default configuration and opts applied.
+// As for the default site, APP_ENV=development or -dev enables DevMode.
+func New(opts ...Option) *Site {
+	s := &Site{
+		config:  defaultConfig(),
+		handler: &siteHandler{cp: crudp.New()},
 	}
-)
+	s.initPlatform()
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
 
+// defaultSite backs the package-level API.
+var defaultSite = New()
 
 func (h *siteHandler) GetUserData() (name, area string) {
 	for _, m := range h.registeredModules {
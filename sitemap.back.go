@@ -0,0 +1,112 @@
+//go:build !wasm
+
+package site
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SitemapProvider is an optional interface for public modules that sets
+// <lastmod> and <priority> of the sitemap.xml root entry, which takes the
+// latest date and the highest priority of the public modules on the page.
+// A zero time or priority omits the element.
+type SitemapProvider interface {
+	SitemapLastMod() time.Time
+	SitemapPriority() float64
+}
+
+type sitemapURL struct {
+	Loc      string `xml:"loc"`
+	LastMod  string `xml:"lastmod,omitempty"`
+	Priority string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapEnabled reports whether sitemap.xml and robots.txt are generated.
+func (s *Site) sitemapEnabled() bool {
+	return s.config.BaseURL != ""
+}
+
+// sitemapXML lists the site root when any module is public: the page holds
+// every public module. Modules are reached through #name/params hash routes,
+// which crawlers fold into the root URL, so they cannot be indexed on their
+// own and are not listed.
+func (s *Site) sitemapXML() ([]byte, error) {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	var lastMod time.Time
+	var priority float64
+	public := false
+	for _, m := range s.handler.registeredModules {
+		if !isPublicReadable(m.handler) {
+			continue
+		}
+		public = true
+		if sp, ok := m.handler.(SitemapProvider); ok {
+			if t := sp.SitemapLastMod(); t.After(lastMod) {
+				lastMod = t
+			}
+			priority = max(priority, sp.SitemapPriority())
+		}
+	}
+	if public {
+		entry := sitemapURL{Loc: strings.TrimSuffix(s.config.BaseURL, "/") + s.config.BasePath + "/"}
+		if !lastMod.IsZero() {
+			entry.LastMod = lastMod.UTC().Format("2006-01-02")
+		}
+		if priority > 0 {
+			entry.Priority = strconv.FormatFloat(priority, 'f', 1, 64)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// robotsTxt disallows the data routes of private modules plus any rules set
+// with SetRobotsDisallow, and points crawlers at sitemap.xml.
+func (s *Site) robotsTxt() []byte {
+	var b bytes.Buffer
+	b.WriteString("User-agent: *\n")
+	for _, m := range s.handler.registeredModules {
+		if !isPublicReadable(m.handler) {
+			b.WriteString("Disallow: " + s.config.BasePath + "/" + m.name + "\n")
+		}
+	}
+	for _, rule := range s.config.RobotsDisallow {
+		b.WriteString("Disallow: " + rule + "\n")
+	}
+	b.WriteString("\nSitemap: " + strings.TrimSuffix(s.config.BaseURL, "/") + s.config.BasePath + "/sitemap.xml\n")
+	return b.Bytes()
+}
+
+// registerSitemapRoutes serves sitemap.xml and robots.txt generated at Mount time.
+func (s *Site) registerSitemapRoutes(mux *http.ServeMux) error {
+	sitemap, err := s.sitemapXML()
+	if err != nil {
+		return err
+	}
+	robots := s.robotsTxt()
+	mux.HandleFunc("GET /sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(sitemap)
+	})
+	mux.HandleFunc("GET /robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(robots)
+	})
+	return nil
+}
@@ -3,8 +3,15 @@
 package site
 
 import (
+	"encoding/json"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/tinywasm/assetmin"
 	"github.com/tinywasm/dom"
+	"github.com/tinywasm/fmt"
 )
 
 // trackedComponentsProvider allows site to collect nested components from module builders
@@ -21,25 +28,70 @@ type accessLevel interface {
 	AllowedRoles(action byte) []byte
 }
 
-// ssrBuild registers all assets with assetmin
-func ssrBuild(am *assetmin.AssetMin) error {
+// PlaceholderProvider is an optional interface for private (SPA) modules that
+// supplies skeleton HTML shown until the wasm client renders the module.
+type PlaceholderProvider interface {
+	RenderPlaceholder() string
+}
+
+// StructuredDataProvider is an optional interface for public modules that
+// describe their page with schema.org data (Organization, Article, Product,
+// BreadcrumbList...). StructuredData returns a JSON-LD document; it is
+// validated at build time and embedded in the page head as
+// <script type="application/ld+json">.
+type StructuredDataProvider interface {
+	StructuredData() string
+}
+
+// ssrBuild registers all assets with assetmin.
+// Every module call is guarded: panics and errors are collected per handler
+// and phase and returned together as a *BuildError, or logged and replaced by
+// an error section when SetContinueOnRenderError(true) was called.
+func (s *Site) ssrBuild(am *assetmin.AssetMin) error {
+	start := time.Now()
+	s.ssr.head = nil
+	s.ssr.componentRegistry = &ssrComponentRegistry{}
+	s.ssr.stats = newBuildStats()
+	var failures []*RenderError
+
+	for _, m := range s.handler.registeredModules {
+		entry := s.ssr.stats.module(m.name)
+		entry.Mode = "spa"
+		if isPublicReadable(m.handler) {
+			entry.Mode = "ssr"
+		}
+		if mod, ok := m.handler.(Module); ok {
+			entry.Title = mod.ModuleTitle()
+		}
+	}
+
 	// 1. Module Discovery: Track components used by registered modules
-	for _, m := range handler.registeredModules {
+	for _, m := range s.handler.registeredModules {
 		// If the handler itself is a component, register it
 		if comp, ok := m.handler.(dom.Component); ok {
-			ssr.componentRegistry.register(comp)
+			s.ssr.componentRegistry.register(comp, m.name)
 		}
 
 		// If it's a component, trigger its RenderHTML to collect nested components
 		// (e.g. if it uses a builder internally)
 		if html, ok := m.handler.(dom.Component); ok {
-			_ = html.RenderHTML()
+			if f := s.ssr.stats.guard(m.name, html, PhaseDiscovery, func() error {
+				_ = html.RenderHTML()
+				return nil
+			}); f != nil {
+				failures = append(failures, f)
+			}
 		}
 
 		// Now collect everything tracked if the handler provides them
 		if tcp, ok := m.handler.(trackedComponentsProvider); ok {
-			for _, c := range tcp.TrackedComponents() {
-				ssr.componentRegistry.register(c)
+			if f := s.ssr.stats.guard(m.name, tcp, PhaseDiscovery, func() error {
+				for _, c := range tcp.TrackedComponents() {
+					s.ssr.componentRegistry.register(c, m.name)
+				}
+				return nil
+			}); f != nil {
+				failures = append(failures, f)
 			}
 		}
 	}
@@ -47,35 +99,157 @@ func ssrBuild(am *assetmin.AssetMin) error {
 	// 2. Asset Injection
 
 	// Inject all collected CSS
-	if css := ssr.componentRegistry.collectCSS(); css != "" {
+	css, cssFailures := s.ssr.componentRegistry.collectCSS(s.ssr.stats)
+	failures = append(failures, cssFailures...)
+	s.ssr.stats.report.CSSBytes = len(css)
+	if css != "" {
 		am.InjectHTML("<style>\n" + css + "</style>\n")
 	}
 
 	// Inject all collected JS
-	if js := ssr.componentRegistry.collectJS(); js != "" {
+	js, jsFailures := s.ssr.componentRegistry.collectJS(s.ssr.stats)
+	failures = append(failures, jsFailures...)
+	s.ssr.stats.report.JSBytes = len(js)
+	if js != "" {
 		am.InjectHTML("<script>\n" + js + "</script>\n")
 	}
 
 	// Inject all collected Icons (Global Sprite)
-	for id, svg := range ssr.componentRegistry.collectIcons() {
+	icons, iconFailures := s.ssr.componentRegistry.collectIcons(s.ssr.stats)
+	failures = append(failures, iconFailures...)
+	for id, svg := range icons {
 		am.InjectSpriteIcon(id, svg)
 	}
 
-	// 3. Inject Module HTML (public content)
-	for _, m := range handler.registeredModules {
+	// 3. Inject Module HTML (public content, placeholders for private modules)
+	for _, m := range s.handler.registeredModules {
 		h := m.handler
 		if html, ok := h.(dom.Component); ok {
 			public := isPublicReadable(h)
+			phase := PhasePlaceholder
 			if public {
-				content := html.RenderHTML()
-				if content != "" {
-					am.InjectHTML(content)
+				phase = PhaseHTML
+			}
+			var content string
+			f := s.ssr.stats.guard(m.name, h, phase, func() error {
+				if public {
+					content = html.RenderHTML()
+				} else {
+					content = placeholderHTML(m)
 				}
+				return nil
+			})
+			if f != nil {
+				failures = append(failures, f)
+				content = errorModuleHTML(m)
+			}
+			if content != "" {
+				am.InjectHTML(content)
+			}
+			entry := s.ssr.stats.module(m.name)
+			entry.HTMLBytes = len(content)
+			entry.Files = append(entry.Files, "index.html")
+			if len(entry.Icons) > 0 {
+				entry.Files = append(entry.Files, "icons.svg")
+			}
+		}
+	}
+
+	// 4. Collect structured data (public content) for the page head
+	for _, m := range s.handler.registeredModules {
+		if !isPublicReadable(m.handler) {
+			continue
+		}
+		if sd, ok := m.handler.(StructuredDataProvider); ok {
+			var script string
+			if f := s.ssr.stats.guard(m.name, sd, PhaseStructuredData, func() (err error) {
+				script, err = structuredDataScript(sd.StructuredData())
+				return err
+			}); f != nil {
+				failures = append(failures, f)
+				continue
+			}
+			if script != "" {
+				s.ssr.head = append(s.ssr.head, script)
 			}
 		}
 	}
 
-	return nil
+	if s.config.CSRF {
+		s.ssr.head = append(s.ssr.head, csrfScript)
+	}
+
+	// Components are collected from a map; sort for stable errors and reports
+	sort.SliceStable(failures, func(i, j int) bool {
+		a, b := failures[i], failures[j]
+		if a.Handler != b.Handler {
+			return a.Handler < b.Handler
+		}
+		if a.Phase != b.Phase {
+			return a.Phase < b.Phase
+		}
+		return a.Type < b.Type
+	})
+	s.lastReport = s.ssr.stats.finish(time.Since(start), failures)
+	s.lastReport.BasePath = s.config.BasePath
+	s.metrics.ssrDuration.with(nil).set(time.Since(start).Seconds())
+
+	if len(failures) == 0 {
+		return nil
+	}
+	buildErr := &BuildError{Failures: failures}
+	if s.config.ContinueOnRenderError {
+		for _, f := range failures {
+			s.log().Error("site: render failed", "handler", f.Handler, "type", f.Type, "phase", f.Phase, "err", f.Err)
+		}
+		return nil
+	}
+	return buildErr
+}
+
+// placeholderHTML renders the stand-in of a private module until the wasm
+// client shows it: an element with the module's handler name as id and
+// data-module, which the client removes once the module is rendered into
+// the Mount parent, the module skeleton (or an accessible loading message)
+// and a noscript hint.
+func placeholderHTML(m *registeredModule) string {
+	title := m.name
+	if mod, ok := m.handler.(Module); ok && mod.ModuleTitle() != "" {
+		title = mod.ModuleTitle()
+	}
+	title = html.EscapeString(title)
+	name := html.EscapeString(m.name)
+
+	skeleton := `<p>Loading ` + title + `…</p>`
+	if pp, ok := m.handler.(PlaceholderProvider); ok {
+		if custom := pp.RenderPlaceholder(); custom != "" {
+			skeleton = custom
+		}
+	}
+
+	return `<section id="` + name + `" data-module="` + name + `" data-placeholder aria-busy="true" aria-live="polite">` +
+		skeleton +
+		`<noscript><p>` + title + ` requires JavaScript and a signed-in session.</p></noscript>` +
+		`</section>`
+}
+
+// structuredDataScript validates a JSON-LD document and wraps it in a script tag.
+func structuredDataScript(data string) (string, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return "", nil
+	}
+	if !json.Valid([]byte(data)) {
+		return "", fmt.Err("structured data is not valid JSON")
+	}
+	// "</" cannot appear inside a script element; "<\/" is the same JSON string.
+	data = strings.ReplaceAll(data, "</", `<\/`)
+	return `<script type="application/ld+json">` + data + `</script>`, nil
+}
+
+// pageHead returns the snippets collected by ssrBuild for the page head.
+func (s *Site) pageHead() string {
+	return strings.Join(s.ssr.head, "\n")
 }
 
 func isPublicReadable(handler any) bool {
@@ -0,0 +1,84 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func TestMountBasePath(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("base-home"), site.WithBasePath("app/"), site.WithCSRF(true))
+	if err := s.RegisterHandlers(&mockHandler{name: "base-home", html: `<div><a href="/base-home/1">one</a><a href="/login">host</a><img src="//cdn.example.com/x.png"></div>`, role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "host", http.StatusTeapot) })
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/app/")
+	page := rr.Body.String()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /app/ = %d", rr.Code)
+	}
+	for _, want := range []string{`href="/app/style.css"`, `src="/app/script.js"`, `href="/app/base-home/1"`, `src="//cdn.example.com/x.png"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %s:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, `href="/style.css"`) {
+		t.Error("page still links the root stylesheet")
+	}
+	if !strings.Contains(page, `href="/login"`) {
+		t.Error("host links must not be moved under the base path")
+	}
+	if c := rr.Result().Cookies(); len(c) != 1 || c[0].Path != "/app/" {
+		t.Errorf("CSRF cookie = %v, want Path=/app/", c)
+	}
+
+	if rr := get("/app/style.css"); rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("GET /app/style.css = %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if rr := get("/style.css"); rr.Code != http.StatusTeapot {
+		t.Errorf("GET /style.css should reach the host mux, got %d", rr.Code)
+	}
+	if rr := get("/app"); rr.Header().Get("Location") != "/app/" {
+		t.Errorf("GET /app should redirect to /app/, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestBuildStaticBasePath(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDefaultRoute("base-static"), site.WithBasePath("/docs"), site.WithLinkCheck(true))
+	if err := s.RegisterHandlers(&mockHandler{name: "base-static", html: `<div><a href="/">home</a></div>`, role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client.wasm"), []byte("\x00asm\x01\x00\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "index.html"))
+	if !strings.Contains(string(index), `href="/docs/style.css"`) || !strings.Contains(string(index), `href="/docs/"`) {
+		t.Errorf("index.html URLs not under /docs:\n%s", index)
+	}
+	if js, _ := os.ReadFile(filepath.Join(dir, "script.js")); !strings.Contains(string(js), `fetch("/docs/client.wasm")`) {
+		t.Errorf("script.js does not load /docs/client.wasm:\n%.300s", js)
+	}
+}
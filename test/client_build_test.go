@@ -0,0 +1,48 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func TestBuildStatic_ClientLoader(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("wasm-static")
+	defer site.SetDefaultRoute("home")
+	if err := site.RegisterHandlers(&mockHandler{name: "wasm-static", html: "<div>Client</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	// Without client.wasm the bundle carries no loader
+	plain := t.TempDir()
+	if err := site.BuildStatic(plain); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	if js, _ := os.ReadFile(filepath.Join(plain, "script.js")); strings.Contains(string(js), "client.wasm") {
+		t.Error("script.js should not load client.wasm when none was built")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client.wasm"), []byte("\x00asm\x01\x00\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	js, err := os.ReadFile(filepath.Join(dir, "script.js"))
+	if err != nil {
+		t.Fatalf("script.js not written: %v", err)
+	}
+	if !strings.Contains(string(js), `fetch("client.wasm")`) {
+		t.Errorf("script.js does not load client.wasm:\n%.300s", js)
+	}
+	if !strings.Contains(string(js), "importObject") {
+		t.Error("script.js is missing the wasm_exec runtime")
+	}
+}
@@ -0,0 +1,136 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return out
+}
+
+func TestBuildStatic_Precompress(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("gz-module")
+	defer site.SetDefaultRoute("home")
+	site.SetPrecompress(true)
+	defer site.SetPrecompress(false)
+
+	if err := site.RegisterHandlers(&mockHandler{name: "gz-module", html: "<div>Compressed</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	gz, err := os.ReadFile(filepath.Join(dir, "index.html.gz"))
+	if err != nil {
+		t.Fatalf("index.html.gz not written: %v", err)
+	}
+	if !bytes.Equal(gunzip(t, gz), index) {
+		t.Error("index.html.gz does not decompress to index.html")
+	}
+}
+
+func TestMount_PrecompressedServing(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("gz-mount")
+	defer site.SetDefaultRoute("home")
+	site.SetDevMode(true)
+	site.SetPrecompress(true)
+	defer site.SetPrecompress(false)
+
+	if err := site.RegisterHandlers(&mockHandler{name: "gz-mount", html: "<div>Served</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	if err := site.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	plain := httptest.NewRecorder()
+	mux.ServeHTTP(plain, httptest.NewRequest("GET", "/", nil))
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Errorf("unexpected Content-Encoding without Accept-Encoding: %q", plain.Header().Get("Content-Encoding"))
+	}
+	if plain.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", plain.Header().Get("Vary"))
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "br;q=0, gzip")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rr.Header().Get("Content-Encoding"))
+	}
+	if !bytes.Equal(gunzip(t, rr.Body.Bytes()), plain.Body.Bytes()) {
+		t.Error("gzip response does not match the identity response")
+	}
+}
+
+func TestMount_PrecompressedConditional(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("gz-etag")
+	defer site.SetDefaultRoute("home")
+	site.SetDevMode(true)
+	site.SetPrecompress(true)
+	defer site.SetPrecompress(false)
+
+	if err := site.RegisterHandlers(&mockHandler{name: "gz-etag", html: "<div>Cached</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := site.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	get := func(encoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	plain, gz := get("", ""), get("gzip", "")
+	etag := plain.Header().Get("ETag")
+	if etag == "" || gz.Header().Get("ETag") == "" || etag == gz.Header().Get("ETag") {
+		t.Fatalf("ETags identity %q, gzip %q: want two distinct tags", etag, gz.Header().Get("ETag"))
+	}
+	if rr := get("", etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("matching If-None-Match: status %d with %d bytes, want an empty 304", rr.Code, rr.Body.Len())
+	}
+	if rr := get("gzip", etag); rr.Code != http.StatusOK || rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("identity ETag on a gzip request: status %d, Content-Encoding %q, want the gzip 200", rr.Code, rr.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,111 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func writeConfig(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	out := t.TempDir()
+	path := writeConfig(t, "site.toml", `# site settings
+cache_size = 5
+default_route = "cfg-home" # landing page
+output_dir = `+out+`
+robots_disallow = ["/tmp/", "/drafts/"]
+csrf = true
+`)
+	t.Setenv("SITE_CACHE_SIZE", "7")
+	t.Setenv("SITE_BASE_PATH", "/docs/")
+
+	s := site.New(site.WithCacheSize(1), site.WithPrecompress(true))
+	if err := s.LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s.SetMetricsPath("/metrics")
+	c := site.TestSiteConfig(s)
+	if c.CacheSize != 7 || c.DefaultRoute != "cfg-home" || c.OutputDir != out || !c.CSRF || !c.Precompress || c.BasePath != "/docs" || c.MetricsPath != "/metrics" {
+		t.Errorf("config = %+v", c)
+	}
+	if !slices.Equal(c.RobotsDisallow, []string{"/tmp/", "/drafts/"}) {
+		t.Errorf("robots_disallow = %q", c.RobotsDisallow)
+	}
+
+	json := writeConfig(t, "site.json", `{"default_route": "cfg-json", "dev_mode": true, "cache_size": 2}`)
+	t.Setenv(site.ConfigFileEnv, json)
+	s = site.New(site.WithConfig(""))
+	if c := site.TestSiteConfig(s); c.DefaultRoute != "cfg-json" || !c.DevMode || c.CacheSize != 7 {
+		t.Errorf("SITE_CONFIG json config = %+v", c)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	path := writeConfig(t, "site.json", `{"cache_size": "many", "dev_mod": true, "robots_disallow": ["/a/", 1]}`)
+	t.Setenv("SITE_CSRF", "yes please")
+
+	var cerr *site.ConfigError
+	err := site.New().LoadConfig(path)
+	if !errors.As(err, &cerr) {
+		t.Fatalf("LoadConfig error = %v, want *site.ConfigError", err)
+	}
+	want := []string{
+		path + `: cache_size: "many" is not an integer`,
+		path + `: dev_mod: unknown key "dev_mod"`,
+		path + `: robots_disallow: list items must be strings`,
+		`SITE_CSRF: "yes please" is not true or false`,
+	}
+	if !slices.Equal(cerr.Problems, want) {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(cerr.Problems, "\n"), strings.Join(want, "\n"))
+	}
+
+	toml := writeConfig(t, "site.conf", "cache_size = 3\njust text\n")
+	if err := site.New().LoadConfig(toml); err == nil || !strings.Contains(err.Error(), toml+":2: expected key = value") {
+		t.Errorf("malformed line error = %v", err)
+	}
+	if err := site.New().LoadConfig(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("a missing config file should fail")
+	}
+}
+
+func TestMountReportsConfigErrors(t *testing.T) {
+	t.Setenv("SITE_CACHE_SIZE", "-1")
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithConfig(""), site.WithDefaultRoute("cfg-missing"), site.WithBaseURL("example.com"))
+	if err := s.RegisterHandlers(&mockHandler{name: "cfg-present", html: "<div>Present</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	var cerr *site.ConfigError
+	if err := s.Mount(http.NewServeMux()); !errors.As(err, &cerr) {
+		t.Fatalf("Mount error = %v, want *site.ConfigError", err)
+	}
+	want := []string{
+		"cache_size: must be at least 0, got -1",
+		`default_route: no module named "cfg-missing" (registered: cfg-present)`,
+		`base_url: must be an absolute http(s) URL, got "example.com"`,
+	}
+	if !slices.Equal(cerr.Problems, want) {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(cerr.Problems, "\n"), strings.Join(want, "\n"))
+	}
+
+	t.Setenv("SITE_CACHE_SIZE", "x")
+	s = site.New(site.WithConfig(""))
+	if err := s.BuildStatic(t.TempDir()); err == nil || !strings.Contains(err.Error(), `SITE_CACHE_SIZE: "x" is not an integer`) {
+		t.Errorf("BuildStatic should report the LoadConfig error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+var styleBlock = regexp.MustCompile(`(?s)<style>(.*?)</style>`)
+
+// styledHandler is a distinct component type so its CSS is collected even
+// after other tests registered plain mockHandlers.
+type styledHandler struct{ mockHandler }
+
+func styleHash(t *testing.T, page string) string {
+	t.Helper()
+	m := styleBlock.FindStringSubmatch(page)
+	if m == nil {
+		t.Fatalf("no inline <style> block in page")
+	}
+	sum := sha256.Sum256([]byte(m[1]))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+func TestBuildStatic_CSPMeta(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("csp-static")
+	defer site.SetDefaultRoute("home")
+	site.SetContentSecurityPolicy(site.DefaultContentSecurityPolicy + "; frame-ancestors 'none'")
+	defer site.SetContentSecurityPolicy("")
+
+	h := &styledHandler{mockHandler{name: "csp-static", html: "<div>CSP</div>", css: ".csp-static{color:red}", role: '*'}}
+	if err := site.RegisterHandlers(h); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	page := string(data)
+
+	if !strings.Contains(page, `<meta http-equiv="Content-Security-Policy"`) {
+		t.Fatalf("CSP meta tag missing:\n%s", page)
+	}
+	if !strings.Contains(page, styleHash(t, page)) {
+		t.Errorf("CSP meta does not contain the inline style hash")
+	}
+	if strings.Contains(page, "frame-ancestors") {
+		t.Errorf("frame-ancestors must not be emitted in a meta policy")
+	}
+}
+
+func TestMount_CSPHeader(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("csp-mount")
+	defer site.SetDefaultRoute("home")
+	site.SetDevMode(true)
+	site.SetContentSecurityPolicy(site.DefaultContentSecurityPolicy)
+	defer site.SetContentSecurityPolicy("")
+
+	h := &styledHandler{mockHandler{name: "csp-mount", html: "<div>CSP</div>", css: ".csp-mount{color:blue}", role: '*'}}
+	if err := site.RegisterHandlers(h); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	if err := site.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	policy := rr.Header().Get("Content-Security-Policy")
+	if !strings.HasPrefix(policy, "default-src 'self'") {
+		t.Fatalf("Content-Security-Policy = %q", policy)
+	}
+	if !strings.Contains(policy, styleHash(t, rr.Body.String())) {
+		t.Errorf("policy %q does not contain the inline style hash", policy)
+	}
+	if strings.Contains(policy, "unsafe-inline") {
+		t.Errorf("policy must not require unsafe-inline: %q", policy)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func mountCSRF(t *testing.T, opts ...site.Option) *http.ServeMux {
+	t.Helper()
+	s := site.New(append([]site.Option{site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("csrf-home"), site.WithCSRF(true)}, opts...)...)
+	if err := s.RegisterHandlers(&mockHandler{name: "csrf-home", html: "<div>Home</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	return mux
+}
+
+func TestCSRFIssuesTokenWithPage(t *testing.T) {
+	t.Parallel()
+	mux := mountCSRF(t)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != site.CSRFCookie || len(cookies[0].Value) != 64 || cookies[0].HttpOnly {
+		t.Fatalf("page should issue a script-readable token cookie: %+v", cookies)
+	}
+	if !strings.Contains(rr.Body.String(), site.CSRFHeader) {
+		t.Error("page should carry the fetch wrapper sending the token header")
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", site.CSRFPath, nil)
+	req.AddCookie(cookies[0])
+	mux.ServeHTTP(rr, req)
+	var body struct{ Token string }
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Token != cookies[0].Value {
+		t.Errorf("token endpoint = %q, %v; want the existing cookie token", rr.Body.String(), err)
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("an existing token should not be replaced")
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", site.CSRFPath, nil))
+	issued := rr.Result().Cookies()
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || len(issued) != 1 || body.Token != issued[0].Value {
+		t.Errorf("token endpoint without cookie = %q, cookies %+v", rr.Body.String(), issued)
+	}
+}
+
+func TestCSRFChecksUnsafeRequests(t *testing.T) {
+	t.Parallel()
+	bearer := func(r *http.Request) bool { return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") }
+	mux := mountCSRF(t, site.WithCSRFExempt(bearer))
+	const token = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	for _, c := range []struct {
+		name           string
+		cookie, header string
+		extra          map[string]string
+		forbidden      bool
+	}{
+		{"no token", "", "", nil, true},
+		{"header mismatch", token, "other", nil, true},
+		{"double submit", token, token, nil, false},
+		{"same origin", token, token, map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"}, false},
+		{"cross-site fetch", token, token, map[string]string{"Sec-Fetch-Site": "cross-site"}, true},
+		{"foreign origin", token, token, map[string]string{"Origin": "https://evil.example"}, true},
+		{"exempt bearer client", "", "", map[string]string{"Authorization": "Bearer api-key"}, false},
+	} {
+		req := httptest.NewRequest("POST", "/csrf-home/1", strings.NewReader("{}"))
+		if c.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: site.CSRFCookie, Value: c.cookie})
+		}
+		if c.header != "" {
+			req.Header.Set(site.CSRFHeader, c.header)
+		}
+		for k, v := range c.extra {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if (rr.Code == http.StatusForbidden) != c.forbidden {
+			t.Errorf("%s: status %d, forbidden want %v", c.name, rr.Code, c.forbidden)
+		}
+	}
+}
+
+func TestCSRFDisabledByDefault(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()))
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/anything", nil))
+	if rr.Code == http.StatusForbidden || len(rr.Result().Cookies()) != 0 {
+		t.Errorf("CSRF should be off by default: status %d, cookies %v", rr.Code, rr.Result().Cookies())
+	}
+}
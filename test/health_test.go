@@ -0,0 +1,149 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/rbac"
+	"github.com/tinywasm/site"
+)
+
+// queueModule depends on an external queue.
+type queueModule struct {
+	mockHandler
+	err error
+}
+
+func (m *queueModule) Ready(ctx context.Context) error { return m.err }
+
+func TestHealthEndpoints(t *testing.T) {
+	t.Parallel()
+	queue := &queueModule{mockHandler{name: "health-queue", html: "<div>Queue</div>", css: ".queue{}", role: '*'}, errors.New("queue unreachable")}
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("health-queue"), site.WithHealthEndpoints(true))
+	if err := s.RegisterHandlers(queue); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	if rr := get(site.HealthPath); rr.Code != http.StatusOK || rr.Body.String() != "ok\n" {
+		t.Errorf("healthz = %d %q", rr.Code, rr.Body.String())
+	}
+
+	var ready site.ReadyReport
+	rr := get(site.ReadyPath)
+	if err := json.Unmarshal(rr.Body.Bytes(), &ready); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusServiceUnavailable || ready.Ready || ready.Checks["health-queue"] != "health-queue: failing" || ready.Checks["ssr"] != "ok" {
+		t.Errorf("readyz with a failing module = %d %+v", rr.Code, ready)
+	}
+	queue.err = nil
+	if rr := get(site.ReadyPath); rr.Code != http.StatusOK {
+		t.Errorf("readyz once the module recovers = %d %s", rr.Code, rr.Body.String())
+	}
+
+	var version site.VersionInfo
+	if err := json.Unmarshal(get(site.VersionPath).Body.Bytes(), &version); err != nil {
+		t.Fatal(err)
+	}
+	if version.GoVersion == "" || len(version.Modules) != 1 || version.Modules[0] != "health-queue" {
+		t.Errorf("version = %+v", version)
+	}
+	if len(version.Assets["/style.css"]) != 64 || len(version.Assets["/"]) != 64 {
+		t.Errorf("version should hash the page and assets: %v", version.Assets)
+	}
+	for key := range version.Settings {
+		if !strings.HasPrefix(key, "vcs.") && key != "GOOS" && key != "GOARCH" {
+			t.Errorf("version publishes build setting %q", key)
+		}
+	}
+}
+
+func TestReadyWithContinueOnRenderError(t *testing.T) {
+	t.Parallel()
+	bad := &crashingHandler{mockHandler: mockHandler{name: "ready-crashing", role: '*'}}
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("ready-crashing"),
+		site.WithHealthEndpoints(true), site.WithContinueOnRenderError(true), site.WithLogger(&recordLogger{}))
+	if err := s.RegisterHandlers(bad); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", site.ReadyPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("readyz after a render failure the build kept going past = %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHealthEndpointsDisabledByDefault(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()))
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", site.ReadyPath, nil))
+	if rr.Body.String() == "ok\n" || rr.Header().Get("Content-Type") == "application/json" {
+		t.Errorf("readyz should not be registered: %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+// slowDB answers SELECT 1 after delay with err, counting queries in calls.
+type slowDB struct {
+	delay time.Duration
+	err   error
+	calls *atomic.Int32
+}
+
+func (db slowDB) Exec(query string, args ...any) error { return nil }
+func (db slowDB) QueryRow(query string, args ...any) rbac.Scanner {
+	if db.calls != nil {
+		db.calls.Add(1)
+	}
+	time.Sleep(db.delay)
+	return db
+}
+func (db slowDB) Query(query string, args ...any) (rbac.Rows, error) { return nil, db.err }
+func (db slowDB) Scan(dest ...any) error                             { return db.err }
+
+func TestPingDB(t *testing.T) {
+	t.Parallel()
+	ping := site.TestPingDB()
+	down := errors.New("connection refused")
+	if err := ping(context.Background(), slowDB{err: down}); !errors.Is(err, down) {
+		t.Errorf("ping = %v, want %v", err, down)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var calls atomic.Int32
+	stuck := slowDB{delay: 200 * time.Millisecond, calls: &calls}
+	for range 3 {
+		if err := ping(ctx, stuck); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ping of a stuck database = %v, want the context deadline", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("stuck database queried %d times, want one in-flight ping", n)
+	}
+}
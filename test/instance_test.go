@@ -0,0 +1,78 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func TestNewSitesOnSeparateMuxes(t *testing.T) {
+	t.Parallel()
+	public := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("instance-home"))
+	admin := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("dashboard"))
+
+	if err := public.RegisterHandlers(&mockHandler{name: "instance-home", html: "<div>Public home</div>", role: '*'}); err != nil {
+		t.Fatalf("public RegisterHandlers failed: %v", err)
+	}
+	if err := admin.RegisterHandlers(&mockHandler{name: "dashboard", html: "<div>Admin dashboard</div>", role: '*'}); err != nil {
+		t.Fatalf("admin RegisterHandlers failed: %v", err)
+	}
+
+	for _, c := range []struct {
+		s         *site.Site
+		want, not string
+	}{
+		{public, "Public home", "Admin dashboard"},
+		{admin, "Admin dashboard", "Public home"},
+	} {
+		mux := http.NewServeMux()
+		if err := c.s.Mount(mux); err != nil {
+			t.Fatalf("Mount failed: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		body := rr.Body.String()
+		if !strings.Contains(body, c.want) || strings.Contains(body, c.not) {
+			t.Errorf("page should contain %q and not %q:\n%s", c.want, c.not, body)
+		}
+		if routes := c.s.Routes(); len(routes) != 1 {
+			t.Errorf("Routes() = %+v, want only the site's own handler", routes)
+		}
+		if c.s.LastBuildReport() == nil {
+			t.Error("each site should keep its own build report")
+		}
+	}
+
+	for _, r := range site.Routes() {
+		if r.Name == "instance-home" || r.Name == "dashboard" {
+			t.Errorf("handler %q leaked into the default site", r.Name)
+		}
+	}
+}
+
+func TestNewSiteBuildStatic(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithBaseURL("https://admin.example.com"), site.WithDefaultRoute("instance-static"))
+	if err := s.RegisterHandlers(&mockHandler{name: "instance-static", html: "<div>Static instance</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	out := t.TempDir()
+	if err := s.BuildStatic(out); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	index, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil || !strings.Contains(string(index), "Static instance") {
+		t.Errorf("index.html = %q, %v", index, err)
+	}
+	sitemap, err := os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil || !strings.Contains(string(sitemap), "<loc>https://admin.example.com/</loc>") {
+		t.Errorf("sitemap.xml should use the site's own base URL: %q, %v", sitemap, err)
+	}
+}
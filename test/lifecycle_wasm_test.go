@@ -3,6 +3,8 @@
 package site_test
 
 import (
+	"strings"
+	"syscall/js"
 	"testing"
 
 	"github.com/tinywasm/dom"
@@ -86,3 +88,40 @@ func TestModuleLifecycle(t *testing.T) {
 		t.Errorf("m2 params = %v, want [123]", m2.params)
 	}
 }
+
+// htmlModule renders its name as content.
+type htmlModule struct{ TestModule }
+
+func (m *htmlModule) RenderHTML() string { return `<p class="content">` + m.name + `</p>` }
+
+func TestNavigateReplacesPreviousModule(t *testing.T) {
+	doc := js.Global().Get("document")
+	if doc.IsUndefined() {
+		t.Skip("no DOM")
+	}
+	body := doc.Get("body")
+	body.Set("innerHTML", `<div id="nav-app"></div><section id="nav-b" data-module="nav-b" data-placeholder aria-busy="true"><p>Loading</p></section>`)
+
+	a := &htmlModule{TestModule{name: "nav-a"}}
+	b := &htmlModule{TestModule{name: "nav-b"}}
+	site.TestResetHandler()
+	site.TestResetWasm()
+	site.RegisterHandlers(a, b)
+
+	if err := site.Navigate("nav-app", "#nav-a"); err != nil {
+		t.Fatalf("Navigate to A failed: %v", err)
+	}
+	if err := site.Navigate("nav-app", "#nav-b"); err != nil {
+		t.Fatalf("Navigate to B failed: %v", err)
+	}
+	page := body.Get("innerHTML").String()
+	if strings.Contains(page, ">nav-a<") {
+		t.Errorf("module A still mounted after navigating to B:\n%s", page)
+	}
+	if !strings.Contains(doc.Call("getElementById", "nav-app").Get("innerHTML").String(), ">nav-b<") {
+		t.Errorf("module B not rendered into the Mount parent:\n%s", page)
+	}
+	if strings.Contains(page, "data-placeholder") {
+		t.Errorf("placeholder of B not removed:\n%s", page)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tinywasm/site"
+	"github.com/tinywasm/site/linkcheck"
+)
+
+func TestBuildStatic_LinkCheck(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("links-ok")
+	defer site.SetDefaultRoute("home")
+	site.SetLinkCheck(true)
+	defer site.SetLinkCheck(false)
+
+	if err := site.RegisterHandlers(
+		&mockHandler{name: "links-ok", html: `<a href="#links-broken/1">next</a>`, role: '*'},
+		&mockHandler{name: "links-broken", html: `<a href="#links-renamed">prev</a>`, role: '*'},
+	); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	err := site.BuildStatic(t.TempDir())
+	var problems linkcheck.Problems
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected linkcheck.Problems, got %v", err)
+	}
+	if len(problems) != 1 || problems[0].URL != "#links-renamed" || problems[0].File != "index.html" || problems[0].Line == 0 {
+		t.Errorf("problems = %v", problems)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+var _ site.Logger = slog.Default()
+
+// logEntry is one recorded Logger call.
+type logEntry struct {
+	level, msg string
+	fields     map[string]any
+}
+
+// recordLogger keeps every entry.
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordLogger) add(level, msg string, args []any) {
+	fields := make(map[string]any)
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[args[i].(string)] = args[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg, fields})
+}
+
+func (l *recordLogger) Debug(msg string, args ...any) { l.add("debug", msg, args) }
+func (l *recordLogger) Info(msg string, args ...any)  { l.add("info", msg, args) }
+func (l *recordLogger) Warn(msg string, args ...any)  { l.add("warn", msg, args) }
+func (l *recordLogger) Error(msg string, args ...any) { l.add("error", msg, args) }
+
+func (l *recordLogger) find(msg string) *logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.entries {
+		if l.entries[i].msg == msg {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func TestLoggerReceivesRenderFailures(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	s := site.New(site.WithDefaultRoute("log-crashing"), site.WithContinueOnRenderError(true), site.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	bad := &crashingHandler{mockHandler: mockHandler{name: "log-crashing", role: '*'}}
+	if err := s.RegisterHandlers(bad); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if err := s.BuildStatic(t.TempDir()); err != nil {
+		t.Fatalf("BuildStatic should continue: %v", err)
+	}
+
+	var entry struct{ Level, Msg, Handler, Phase, Err string }
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("log line %q: %v", line, err)
+	}
+	if entry.Level != "ERROR" || entry.Msg != "site: render failed" || entry.Handler != "log-crashing" || entry.Phase == "" || entry.Err == "" {
+		t.Errorf("entry = %+v", entry)
+	}
+}
+
+func TestLoggerReceivesMountErrorsAndPanics(t *testing.T) {
+	t.Parallel()
+	log := &recordLogger{}
+	if err := site.New(site.WithLogger(log)).Mount(http.NewServeMux()); err == nil {
+		t.Fatal("Mount without SetDB or dev mode should fail")
+	}
+	if e := log.find("site: mount failed"); e == nil || e.level != "error" || e.fields["err"] == nil {
+		t.Errorf("mount failure entry = %+v", e)
+	}
+
+	boom := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	}
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithLogger(log), site.WithMiddleware(site.Recover(), boom))
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/boom", nil))
+	if e := log.find("site: panic serving request"); e == nil || e.fields["route"] != "/boom" || e.fields["panic"] != "boom" {
+		t.Errorf("panic entry = %+v", e)
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("metrics-home"), site.WithMetricsPath("/metrics"))
+	if err := s.RegisterHandlers(&mockHandler{name: "metrics-home", html: "<div>Home</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	s.Metrics().Counter("app_signups_total", "Signups by plan.", "plan").With("pro").Add(2)
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader("")))
+		return rr
+	}
+	serve("GET", "/")
+	serve("GET", "/style.css")
+	serve("POST", "/batch")
+
+	rr := serve("GET", "/metrics")
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rr.Body.String()
+	for _, pattern := range []string{
+		`(?m)^# TYPE site_http_requests_total counter$`,
+		`(?m)^site_http_requests_total\{handler="batch",action="batch",code="\d{3}"\} 1$`,
+		`(?m)^# TYPE site_http_request_duration_seconds histogram$`,
+		`(?m)^site_http_request_duration_seconds_bucket\{handler="batch",action="batch",le="\+Inf"\} 1$`,
+		`(?m)^site_http_request_duration_seconds_count\{handler="batch",action="batch"\} 1$`,
+		`(?m)^site_asset_bytes_served_total\{route="/"\} [1-9]\d*$`,
+		`(?m)^site_asset_bytes_served_total\{route="/style.css"\} \d+$`,
+		`(?m)^# TYPE site_rbac_checks_total counter$`,
+		`(?m)^site_ssr_build_duration_seconds [0-9.e-]+$`,
+		`(?m)^app_signups_total\{plan="pro"\} 2$`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(body) {
+			t.Errorf("metrics missing %s:\n%s", pattern, body)
+		}
+	}
+}
+
+func TestMetricsBatchOperations(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithMetricsPath("/metrics"))
+	h := site.TestMetricsHandler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), ticketAPI{})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/batch", bytes.NewReader(batchBody(t, 'c', 'c', 'd'))))
+
+	var b bytes.Buffer
+	s.Metrics().WriteTo(&b)
+	for _, pattern := range []string{
+		`(?m)^site_http_requests_total\{handler="tickets",action="create",code="200"\} 2$`,
+		`(?m)^site_http_requests_total\{handler="tickets",action="delete",code="200"\} 1$`,
+		`(?m)^site_http_request_duration_seconds_count\{handler="tickets",action="create"\} 2$`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(b.String()) {
+			t.Errorf("metrics missing %s:\n%s", pattern, b.String())
+		}
+	}
+	if strings.Contains(b.String(), `handler="batch"`) {
+		t.Errorf("decoded batch still counted as handler=\"batch\":\n%s", b.String())
+	}
+}
+
+func TestMetricRegistry(t *testing.T) {
+	t.Parallel()
+	var r site.MetricRegistry
+	jobs := r.Counter("jobs_total", "Jobs run.\nBy queue.", "queue")
+	jobs.With(`mail "bulk"`).Inc()
+	r.Counter("jobs_total", "ignored", "queue").With(`mail "bulk"`).Add(1.5)
+	jobs.With("a\\b").Add(-1) // ignored
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	want := "# HELP jobs_total Jobs run.\\nBy queue.\n" +
+		"# TYPE jobs_total counter\n" +
+		"jobs_total{queue=\"a\\\\b\"} 0\n" +
+		"jobs_total{queue=\"mail \\\"bulk\\\"\"} 2.5\n"
+	if buf.String() != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering jobs_total with other labels should panic")
+		}
+	}()
+	r.Counter("jobs_total", "Jobs run.", "queue", "status")
+}
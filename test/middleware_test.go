@@ -0,0 +1,173 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/site"
+)
+
+// tag appends name to the X-Order header on the way in.
+func tag(name string) site.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Order", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestUseWrapsAllSiteRoutesInOrder(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithMiddleware(tag("first")), site.WithDefaultRoute("mw-home"))
+	s.Use(tag("second"), site.SecurityHeaders())
+	if err := s.RegisterHandlers(&mockHandler{name: "mw-home", html: "<div>Home</div>", css: ".home{}", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	for _, path := range []string{"/", "/style.css"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if got := strings.Join(rr.Header().Values("X-Order"), ","); got != "first,second" {
+			t.Errorf("%s: middleware order = %q, want first,second", path, got)
+		}
+		if rr.Header().Get("X-Content-Type-Options") != "nosniff" ||
+			rr.Header().Get("Referrer-Policy") != "strict-origin-when-cross-origin" ||
+			!strings.HasPrefix(rr.Header().Get("Strict-Transport-Security"), "max-age=") {
+			t.Errorf("%s: missing security headers: %v", path, rr.Header())
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+	h := site.Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+
+	abort := site.Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) }))
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("http.ErrAbortHandler should be re-raised")
+		}
+	}()
+	abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	t.Parallel()
+	var seen string
+	h := site.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = site.RequestIDFrom(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/users?id=1", nil)
+	req.Header.Set(site.RequestIDHeader, "abc123")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if seen != "abc123" || rr.Header().Get(site.RequestIDHeader) != "abc123" {
+		t.Errorf("incoming request ID not kept: context %q, header %q", seen, rr.Header().Get(site.RequestIDHeader))
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if id := rr.Header().Get(site.RequestIDHeader); id == "" || id == "abc123" {
+		t.Errorf("generated request ID = %q", id)
+	}
+
+	// Mounted, AccessLog logs to the site Logger.
+	log := &recordLogger{}
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("access-home"), site.WithLogger(log), site.WithMiddleware(site.RequestID(), site.AccessLog()))
+	if err := s.RegisterHandlers(&mockHandler{name: "access-home", html: "<div>Home</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := s.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	req = httptest.NewRequest("GET", "/nope?id=1", nil)
+	req.Header.Set(site.RequestIDHeader, "abc123")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	e := log.find("site: request")
+	if e == nil || e.level != "info" {
+		t.Fatalf("no access log entry: %+v", log.entries)
+	}
+	if e.fields["method"] != "GET" || e.fields["route"] != "/nope" || e.fields["status"] != rr.Code ||
+		e.fields["bytes"] != rr.Body.Len() || e.fields["request_id"] != "abc123" {
+		t.Errorf("access log fields = %v", e.fields)
+	}
+	if _, ok := e.fields["duration"].(time.Duration); !ok {
+		t.Errorf("duration = %#v", e.fields["duration"])
+	}
+}
+
+func TestGzip(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat(`{"name":"value"}`, 100)
+	h := site.Gzip()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			w.Header().Set("Content-Type", "application/json")
+		case "/precompressed":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Vary", "Accept-Encoding")
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		}
+		io.WriteString(w, body)
+	}))
+	get := func(path string, gzipOK bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if gzipOK {
+			req.Header.Set("Accept-Encoding", "gzip, br")
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api", true)
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("dynamic JSON should be gzipped: %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != body {
+		t.Error("gzipped body does not round-trip")
+	}
+
+	for _, c := range []struct {
+		path   string
+		gzipOK bool
+		want   string
+	}{
+		{"/api", false, ""},
+		{"/image", true, ""},
+		{"/precompressed", true, "gzip"},
+	} {
+		rr := get(c.path, c.gzipOK)
+		if rr.Header().Get("Content-Encoding") != c.want || rr.Body.String() != body {
+			t.Errorf("%s (gzip accepted: %v) should pass through unchanged: %v", c.path, c.gzipOK, rr.Header())
+		}
+		if vary := rr.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %v, want Accept-Encoding once", c.path, vary)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+type skeletonHandler struct{ mockHandler }
+
+func (h *skeletonHandler) RenderPlaceholder() string { return `<div class="skeleton"></div>` }
+
+func TestBuildStatic_PrivateModulePlaceholders(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("billing")
+	defer site.SetDefaultRoute("home")
+
+	plain := &mockHandler{name: "billing", html: "<div>Invoices</div>", role: 'a'}
+	custom := &skeletonHandler{mockHandler{name: "reports", html: "<div>Reports</div>", role: 'a'}}
+	if err := site.RegisterHandlers(plain, custom); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	page := string(data)
+
+	if strings.Contains(page, "Invoices") || strings.Contains(page, "<div>Reports</div>") {
+		t.Errorf("private module content must not be rendered:\n%s", page)
+	}
+	for _, want := range []string{
+		`<section id="billing" data-module="billing" data-placeholder aria-busy="true" aria-live="polite">`,
+		`<p>Loading billing…</p>`,
+		`<noscript><p>billing requires JavaScript and a signed-in session.</p></noscript>`,
+		`<section id="reports"`,
+		`<div class="skeleton"></div>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %s:\n%s", want, page)
+		}
+	}
+}
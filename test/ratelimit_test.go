@@ -0,0 +1,162 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/binary"
+	"github.com/tinywasm/crudp"
+	"github.com/tinywasm/site"
+)
+
+// limitedAPI allows one create per user on top of the site limit.
+type limitedAPI struct{ ticketAPI }
+
+func (limitedAPI) HandlerName() string { return "limited" }
+func (limitedAPI) RateLimit(action byte) site.RateLimit {
+	if action == 'c' {
+		return site.RateLimit{Rate: 0.01, Burst: 1}
+	}
+	return site.RateLimit{}
+}
+
+func TestRateLimitCrudpRoutes(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithRateLimit(site.RateLimit{Rate: 0.01, Burst: 2}))
+	h := site.TestRateLimitHandler(s, http.NotFoundHandler(), limitedAPI{})
+	do := func(method, path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i, c := range []struct {
+		method, path, ip string
+		limited          bool
+	}{
+		{"POST", "/limited/1", "10.0.0.1", false},
+		{"POST", "/limited/2", "10.0.0.1", true},    // per-action burst used up
+		{"DELETE", "/limited/1", "10.0.0.1", false}, // denied requests take no site tokens
+		{"DELETE", "/limited/2", "10.0.0.1", true},  // site burst used up
+		{"GET", "/", "10.0.0.1", false},             // assets are not limited
+		{"POST", "/limited/1", "10.0.0.2", false},   // separate client
+	} {
+		rr := do(c.method, c.path, c.ip)
+		if (rr.Code == http.StatusTooManyRequests) != c.limited {
+			t.Errorf("request %d %s %s from %s: status %d, limited want %v", i, c.method, c.path, c.ip, rr.Code, c.limited)
+		}
+		if c.limited && rr.Header().Get("Retry-After") != "100" {
+			t.Errorf("request %d: Retry-After = %q, want 100", i, rr.Header().Get("Retry-After"))
+		}
+	}
+}
+
+// batchBody encodes a crudp /batch request with one packet per action for
+// the first registered handler.
+func batchBody(t *testing.T, actions ...byte) []byte {
+	t.Helper()
+	var req crudp.BatchRequest
+	for _, a := range actions {
+		req.Packets = append(req.Packets, crudp.Packet{Action: a, HandlerID: 0})
+	}
+	var body []byte
+	if err := binary.Encode(&req, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestRateLimitBatch(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithRateLimit(site.RateLimit{Rate: 0.01, Burst: 2}))
+	var seen string
+	h := site.TestRateLimitHandler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = string(body)
+	}), limitedAPI{})
+	batch := func(ip string, actions ...byte) (*httptest.ResponseRecorder, string) {
+		body := batchBody(t, actions...)
+		r := httptest.NewRequest("POST", "/batch", bytes.NewReader(body))
+		r.RemoteAddr = ip + ":1234"
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr, string(body)
+	}
+
+	for i, c := range []struct {
+		ip      string
+		actions []byte
+		limited bool
+		retry   bool // Retry-After expected: the batch fits the bursts
+	}{
+		{"10.0.0.1", []byte("ccc"), true, false}, // three creates against a per-action burst of 1
+		{"10.0.0.1", []byte("c"), false, false},  // the refused batch took no tokens
+		{"10.0.0.2", []byte("ddd"), true, false}, // three operations against a site burst of 2
+		{"10.0.0.2", []byte("dd"), false, false},
+		{"10.0.0.2", []byte("d"), true, true},    // the batch used up the site burst
+		{"10.0.0.3", []byte("cc"), true, false},  // two creates from a fresh client
+		{"10.0.0.3", []byte("cd"), false, false}, // one create and one delete fit
+	} {
+		seen = ""
+		rr, body := batch(c.ip, c.actions...)
+		if (rr.Code == http.StatusTooManyRequests) != c.limited {
+			t.Errorf("batch %d %q from %s: status %d, limited want %v", i, c.actions, c.ip, rr.Code, c.limited)
+		}
+		if (rr.Header().Get("Retry-After") != "") != c.retry {
+			t.Errorf("batch %d %q: Retry-After %q, want set %v", i, c.actions, rr.Header().Get("Retry-After"), c.retry)
+		}
+		if !c.limited && seen != body {
+			t.Errorf("batch %d: handler read %d bytes, want the %d-byte body", i, len(seen), len(body))
+		}
+	}
+}
+
+func TestRateLimiterRefillAndBound(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	take, size := site.TestRateLimiter(2, func() time.Time { return now })
+	limit := site.RateLimit{Rate: 2, Burst: 1}
+
+	if wait := take("a", limit); wait != 0 {
+		t.Fatalf("first request waited %v", wait)
+	}
+	if wait := take("a", limit); wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want 500ms at 2 requests/s", wait)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if wait := take("a", limit); wait != 0 {
+		t.Errorf("bucket should refill after 500ms, wait = %v", wait)
+	}
+
+	take("b", limit)
+	take("c", limit)
+	if n := size(); n != 2 {
+		t.Errorf("buckets kept = %d, want 2", n)
+	}
+	if wait := take("a", limit); wait != 0 {
+		t.Errorf("least recently used bucket should have been dropped, wait = %v", wait)
+	}
+}
+
+func TestRateLimitBatchTooLarge(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithRateLimit(site.RateLimit{Rate: 1, Burst: 1}))
+	called := false
+	h := site.TestRateLimitHandler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}), limitedAPI{})
+	r := httptest.NewRequest("POST", "/batch", bytes.NewReader(make([]byte, 2<<20)))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	if rr.Code != http.StatusRequestEntityTooLarge || called {
+		t.Errorf("oversized batch: status %d, handler called %v; want 413 before the handler", rr.Code, called)
+	}
+}
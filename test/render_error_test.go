@@ -0,0 +1,91 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+type crashingHandler struct {
+	mockHandler
+	data *struct{ html string }
+}
+
+// RenderHTML dereferences a nil pointer, like a module missing its setup.
+func (h *crashingHandler) RenderHTML() string { return h.data.html }
+func (h *crashingHandler) RenderCSS() string  { panic("css exploded") }
+
+func TestSSRBuild_AggregatesRenderPanics(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("healthy")
+	defer site.SetDefaultRoute("home")
+
+	ok := &mockHandler{name: "healthy", html: "<div>Healthy</div>", role: '*'}
+	bad := &crashingHandler{mockHandler: mockHandler{name: "crashing", role: '*'}}
+	if err := site.RegisterHandlers(ok, bad); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	err := site.BuildStatic(t.TempDir())
+	var buildErr *site.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("expected *site.BuildError, got %v", err)
+	}
+
+	phases := map[string]bool{}
+	for _, f := range buildErr.Failures {
+		if f.Handler != "crashing" || f.Type != "*site_test.crashingHandler" {
+			t.Errorf("unexpected failure owner: %+v", f)
+		}
+		phases[f.Phase] = true
+	}
+	for _, p := range []string{site.PhaseDiscovery, site.PhaseCSS, site.PhaseHTML} {
+		if !phases[p] {
+			t.Errorf("missing %s failure in %v", p, err)
+		}
+	}
+	for i := 1; i < len(buildErr.Failures); i++ {
+		if buildErr.Failures[i-1].Phase > buildErr.Failures[i].Phase {
+			t.Errorf("failures should be sorted by handler and phase: %v", err)
+		}
+	}
+	if !strings.Contains(err.Error(), "nil pointer dereference") {
+		t.Errorf("error should carry the panic message: %v", err)
+	}
+}
+
+func TestSSRBuild_ContinueOnRenderError(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("healthy")
+	defer site.SetDefaultRoute("home")
+	site.SetContinueOnRenderError(true)
+	defer site.SetContinueOnRenderError(false)
+
+	ok := &mockHandler{name: "healthy", html: "<div>Healthy</div>", role: '*'}
+	bad := &crashingHandler{mockHandler: mockHandler{name: "crashing", role: '*'}}
+	if err := site.RegisterHandlers(ok, bad); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic should continue, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	page := string(data)
+	if !strings.Contains(page, "<div>Healthy</div>") {
+		t.Errorf("healthy module missing:\n%s", page)
+	}
+	if !strings.Contains(page, `<section id="crashing" data-module="crashing" data-render-error role="alert">`) {
+		t.Errorf("crashing module not substituted:\n%s", page)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+type reportedHandler struct{ mockHandler }
+
+func TestBuildStatic_Report(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("catalog")
+	defer site.SetDefaultRoute("home")
+
+	public := &reportedHandler{mockHandler{name: "catalog", html: "<div>Catalog</div>", css: ".catalog{color:red}", role: '*'}}
+	private := &mockHandler{name: "orders", html: "<div>Orders</div>", role: 'a'}
+	if err := site.RegisterHandlers(public, private); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	report := site.LastBuildReport()
+	if report == nil {
+		t.Fatal("LastBuildReport returned nil after BuildStatic")
+	}
+	if report.OutputDir != dir {
+		t.Errorf("OutputDir = %q, want %q", report.OutputDir, dir)
+	}
+	if len(report.Modules) != 2 {
+		t.Fatalf("expected 2 modules, got %+v", report.Modules)
+	}
+
+	catalog, orders := report.Modules[0], report.Modules[1]
+	if catalog.Name != "catalog" || catalog.Mode != "ssr" || catalog.HTMLBytes == 0 {
+		t.Errorf("unexpected catalog entry: %+v", catalog)
+	}
+	if catalog.CSSBytes != len(".catalog{color:red}") {
+		t.Errorf("catalog CSSBytes = %d", catalog.CSSBytes)
+	}
+	if orders.Name != "orders" || orders.Mode != "spa" {
+		t.Errorf("unexpected orders entry: %+v", orders)
+	}
+
+	files := map[string]bool{}
+	for _, f := range report.Files {
+		files[f.Path] = f.Bytes > 0
+	}
+	if !files["index.html"] {
+		t.Errorf("index.html missing from report files: %+v", report.Files)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if _, ok := decoded["modules"]; !ok {
+		t.Errorf("JSON report has no modules key: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "catalog") || !strings.Contains(buf.String(), "MODE") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
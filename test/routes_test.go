@@ -0,0 +1,65 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinywasm/assetmin"
+	"github.com/tinywasm/site"
+)
+
+// ticketAPI is a CRUD handler without a module view.
+type ticketAPI struct{}
+
+func (ticketAPI) HandlerName() string                         { return "tickets" }
+func (ticketAPI) Create(data ...any) any                      { return nil }
+func (ticketAPI) Delete(data ...any) any                      { return nil }
+func (ticketAPI) ValidateData(action byte, data ...any) error { return nil }
+func (ticketAPI) AllowedRoles(action byte) []byte             { return []byte("ae") }
+
+func TestRoutes(t *testing.T) {
+	site.TestResetHandler()
+	public := &styledHandler{mockHandler{name: "routes-public", html: "<div>Public</div>", css: ".routes-public{color:red}", role: '*'}}
+	private := &mockHandler{name: "routes-private", html: "<div>Private</div>"}
+	if err := site.RegisterHandlers(public, private); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if err := site.TestSSRBuild(assetmin.NewAssetMin(&assetmin.Config{OutputDir: t.TempDir()})); err != nil {
+		t.Fatalf("ssrBuild failed: %v", err)
+	}
+
+	routes := site.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d: %+v", len(routes), routes)
+	}
+
+	pub := routes[0]
+	if pub.Name != "routes-public" || pub.Title != "routes-public" || pub.Mode != "ssr" {
+		t.Errorf("public route = %+v", pub)
+	}
+	if pub.Roles["read"] != "*" || len(pub.Roles) != 1 {
+		t.Errorf("public roles = %v, want read=*", pub.Roles)
+	}
+	if pub.CSSBytes == 0 {
+		t.Error("public route should report its CSS contribution")
+	}
+	if len(pub.Verbs) != 0 {
+		t.Errorf("public verbs = %v, want none", pub.Verbs)
+	}
+
+	priv := routes[1]
+	if priv.Mode != "spa" || priv.Roles["read"] != "u" {
+		t.Errorf("private route = %+v", priv)
+	}
+}
+
+func TestHandlerVerbs(t *testing.T) {
+	if got, want := site.TestHandlerVerbs(ticketAPI{}), []string{"create", "delete"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("verbs = %v, want %v", got, want)
+	}
+	if got := site.TestHandlerVerbs(&mockHandler{}); len(got) != 0 {
+		t.Errorf("verbs = %v, want none", got)
+	}
+}
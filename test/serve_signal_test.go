@@ -0,0 +1,47 @@
+//go:build !wasm && !windows
+
+package site_test
+
+import (
+	"context"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/site"
+)
+
+func TestServeContextStopsOnSIGTERM(t *testing.T) {
+	flushed := false
+	s := site.New()
+	s.OnShutdown(func(ctx context.Context) error {
+		flushed = true
+		return nil
+	})
+	url, cancel, done := startServe(t, s, http.NotFoundHandler())
+	defer cancel()
+
+	// Once a request is answered the signal handler is installed
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case err := <-done:
+		if err != nil || !flushed {
+			t.Errorf("err = %v, flushed = %v; want a graceful stop with hooks run", err, flushed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM did not stop the server")
+	}
+}
@@ -0,0 +1,156 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/site"
+)
+
+// flushHandler records its OnShutdown call.
+type flushHandler struct {
+	mockHandler
+	record func(string)
+}
+
+func (h *flushHandler) OnShutdown(ctx context.Context) error {
+	h.record("handler hook")
+	return nil
+}
+
+// startServe runs the ServeContext loop of s on a local port.
+func startServe(t *testing.T, s *site.Site, h http.Handler, opts ...site.ServeOption) (url string, cancel context.CancelFunc, done <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- site.TestServeListener(s, ctx, ln, h, opts...) }()
+	return "http://" + ln.Addr().String(), cancel, errc
+}
+
+func TestServeContextDrainsInFlightRequests(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, event)
+	}
+
+	s := site.New()
+	if err := s.RegisterHandlers(&flushHandler{mockHandler{name: "flush"}, record}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	s.OnShutdown(func(ctx context.Context) error {
+		record("site hook")
+		return nil
+	})
+
+	entered := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		time.Sleep(100 * time.Millisecond)
+		record("request")
+		io.WriteString(w, "done")
+	})
+	url, cancel, done := startServe(t, s, slow)
+	defer cancel()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-entered
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight response = %q, want done", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("graceful shutdown returned %v", err)
+	}
+	want := []string{"request", "handler hook", "site hook"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] || order[2] != want[2] {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("server should not accept connections after shutdown")
+	}
+}
+
+func TestServeContextShutdownTimeout(t *testing.T) {
+	s := site.New()
+	flushErr := errors.New("flush failed")
+	s.OnShutdown(func(ctx context.Context) error { return flushErr })
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	stuck := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	url, cancel, done := startServe(t, s, stuck, site.WithShutdownTimeout(50*time.Millisecond))
+	defer cancel()
+
+	go http.Get(url)
+	<-entered
+	cancel()
+	err := <-done
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, flushErr) {
+		t.Errorf("err = %v, want the drain timeout and the hook error", err)
+	}
+}
+
+func TestServeContextReadTimeout(t *testing.T) {
+	url, cancel, done := startServe(t, site.New(), http.NotFoundHandler(), site.WithReadTimeout(100*time.Millisecond))
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conn, err := net.Dial("tcp", url[len("http://"):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\n") // headers never finish
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	start := time.Now()
+	io.ReadAll(conn)
+	if time.Since(start) >= 2*time.Second {
+		t.Error("server kept a stalled connection open past the read timeout")
+	}
+}
+
+func TestServeContextTLSMissingCert(t *testing.T) {
+	_, cancel, done := startServe(t, site.New(), http.NotFoundHandler(), site.WithTLS("missing-cert.pem", "missing-key.pem"))
+	defer cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("missing certificate should fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeContext kept running without a certificate")
+	}
+}
@@ -0,0 +1,131 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/site"
+)
+
+type sitemapHandler struct{ mockHandler }
+
+func (h *sitemapHandler) SitemapLastMod() time.Time {
+	return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+}
+func (h *sitemapHandler) SitemapPriority() float64 { return 0.8 }
+
+func TestBuildStatic_SitemapAndRobots(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("articles")
+	site.SetBaseURL("https://example.com/")
+	site.SetRobotsDisallow("/tmp/")
+	defer site.SetDefaultRoute("home")
+	defer site.SetBaseURL("")
+	defer site.SetRobotsDisallow()
+
+	public := &sitemapHandler{mockHandler{name: "articles", html: "<div>Articles</div>", role: '*'}}
+	other := &mockHandler{name: "about", html: "<div>About</div>", role: '*'}
+	private := &mockHandler{name: "admin", html: "<div>Admin</div>", role: 'a'}
+	if err := site.RegisterHandlers(public, other, private); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("sitemap.xml not written: %v", err)
+	}
+	for _, want := range []string{
+		"<loc>https://example.com/</loc>",
+		"<lastmod>2026-03-01</lastmod>",
+		"<priority>0.8</priority>",
+	} {
+		if !strings.Contains(string(sitemap), want) {
+			t.Errorf("sitemap.xml missing %s:\n%s", want, sitemap)
+		}
+	}
+	// Hash routes are not indexable: only the root is listed.
+	if n := strings.Count(string(sitemap), "<loc>"); n != 1 || strings.Contains(string(sitemap), "#") {
+		t.Errorf("sitemap.xml should list the root only:\n%s", sitemap)
+	}
+
+	robots, err := os.ReadFile(filepath.Join(dir, "robots.txt"))
+	if err != nil {
+		t.Fatalf("robots.txt not written: %v", err)
+	}
+	for _, want := range []string{"Disallow: /admin\n", "Disallow: /tmp/", "Sitemap: https://example.com/sitemap.xml"} {
+		if !strings.Contains(string(robots), want) {
+			t.Errorf("robots.txt missing %q:\n%s", want, robots)
+		}
+	}
+}
+
+func TestBuildStatic_SitemapPrivateDefaultRoute(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("admin")
+	site.SetBaseURL("https://example.com")
+	defer site.SetDefaultRoute("home")
+	defer site.SetBaseURL("")
+
+	private := &mockHandler{name: "admin", html: "<div>Admin</div>", role: 'a'}
+	public := &mockHandler{name: "about", html: "<div>About</div>", role: '*'}
+	if err := site.RegisterHandlers(private, public); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	sitemap, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("sitemap.xml not written: %v", err)
+	}
+	// The root still holds the public module.
+	if !strings.Contains(string(sitemap), "<loc>https://example.com/</loc>") {
+		t.Errorf("sitemap.xml missing root entry:\n%s", sitemap)
+	}
+}
+
+func TestMount_SitemapRoutes(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDevMode(true)
+	site.SetDefaultRoute("home")
+	site.SetBaseURL("https://example.com")
+	defer site.SetBaseURL("")
+
+	if err := site.RegisterHandlers(&mockHandler{name: "home", html: "<div>Home</div>", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := site.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Fatalf("sitemap.xml Content-Type = %q", ct)
+	}
+	// The default route is the site root.
+	if !strings.Contains(rr.Body.String(), "<loc>https://example.com/</loc>") {
+		t.Errorf("sitemap.xml missing root entry:\n%s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/robots.txt", nil))
+	if !strings.HasPrefix(rr.Body.String(), "User-agent: *") {
+		t.Errorf("unexpected robots.txt:\n%s", rr.Body.String())
+	}
+}
@@ -0,0 +1,104 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+type articleHandler struct {
+	mockHandler
+	data string
+}
+
+func (h *articleHandler) StructuredData() string { return h.data }
+
+func TestBuildStatic_StructuredDataInHead(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("article")
+	defer site.SetDefaultRoute("home")
+
+	h := &articleHandler{
+		mockHandler: mockHandler{name: "article", html: "<div>Article</div>", role: '*'},
+		data:        `{"@context":"https://schema.org","@type":"Article","headline":"Hi </script>"}`,
+	}
+	private := &articleHandler{
+		mockHandler: mockHandler{name: "secret", html: "<div>Secret</div>", role: 'a'},
+		data:        `{"@type":"Organization","name":"Hidden"}`,
+	}
+	if err := site.RegisterHandlers(h, private); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := site.BuildStatic(dir); err != nil {
+		t.Fatalf("BuildStatic failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	page := string(data)
+
+	head, _, ok := strings.Cut(page, "</head>")
+	if !ok {
+		t.Fatalf("page has no head:\n%s", page)
+	}
+	if !strings.Contains(head, `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Article","headline":"Hi <\/script>"}</script>`) {
+		t.Errorf("JSON-LD missing from head:\n%s", head)
+	}
+	if strings.Contains(page, "Hidden") {
+		t.Errorf("structured data of private modules must not be embedded")
+	}
+}
+
+func TestBuildStatic_StructuredDataInvalidJSON(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("broken")
+	defer site.SetDefaultRoute("home")
+
+	h := &articleHandler{
+		mockHandler: mockHandler{name: "broken", html: "<div>Broken</div>", role: '*'},
+		data:        `{"@type": "Article",}`,
+	}
+	if err := site.RegisterHandlers(h); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	err := site.BuildStatic(t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected invalid JSON error naming the module, got %v", err)
+	}
+}
+
+func TestMount_StructuredDataInHead(t *testing.T) {
+	site.TestResetHandler()
+	site.SetDefaultRoute("product")
+	defer site.SetDefaultRoute("home")
+	site.SetDevMode(true)
+
+	h := &articleHandler{
+		mockHandler: mockHandler{name: "product", html: "<div>Product</div>", role: '*'},
+		data:        `{"@type":"Product","name":"Widget"}`,
+	}
+	if err := site.RegisterHandlers(h); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	if err := site.Mount(mux); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	head, _, _ := strings.Cut(rr.Body.String(), "</head>")
+	if !strings.Contains(head, `{"@type":"Product","name":"Widget"}`) {
+		t.Errorf("JSON-LD missing from served head:\n%s", rr.Body.String())
+	}
+}
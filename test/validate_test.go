@@ -0,0 +1,94 @@
+//go:build !wasm
+
+package site_test
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/site"
+)
+
+// unnamedHandler has no HandlerName method.
+type unnamedHandler struct{}
+
+func TestMountValidatesRegistrations(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("nowhere"), site.WithHealthEndpoints(true))
+	home := &mockHandler{name: "val-home", html: "<div>Home</div>", role: '*'}
+	if err := s.RegisterHandlers(
+		home,
+		home, // the same handler again is fine
+		&mockHandler{name: "val-home", role: '*'},
+		&styledHandler{mockHandler{name: "val-home", role: '*'}},
+		&mockHandler{name: "", role: '*'},
+		unnamedHandler{},
+		&mockHandler{name: "my page", role: '*'},
+		&mockHandler{name: "style.css", role: '*'},
+		&mockHandler{name: "version", role: '*'},
+	); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+
+	var cerr *site.ConfigError
+	if err := s.Mount(http.NewServeMux()); !errors.As(err, &cerr) {
+		t.Fatalf("Mount error = %v, want *site.ConfigError", err)
+	}
+	want := []string{
+		`default_route: no module named "nowhere" (registered: val-home, my page, style.css, version)`,
+		`handler *site_test.mockHandler: HandlerName() is missing or empty`,
+		`handler site_test.unnamedHandler: HandlerName() is missing or empty`,
+		`handler "val-home": registered twice by different *site_test.mockHandler values`,
+		`handler "val-home": registered by both *site_test.mockHandler and *site_test.styledHandler`,
+		`handler "my page": name is not URL-safe (use letters, digits, "-", "_", "." and "~")`,
+		`handler "style.css": name conflicts with the site route /style.css`,
+		`handler "version": name conflicts with the site route /version`,
+	}
+	if !slices.Equal(cerr.Problems, want) {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(cerr.Problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMountAcceptsValidRegistrations(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithDefaultRoute("val-ok"), site.WithMetricsPath("/ops/metrics"))
+	if err := s.RegisterHandlers(&mockHandler{name: "val-ok", html: "<div>OK</div>", role: '*'}, &mockHandler{name: "version", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if err := s.Mount(http.NewServeMux()); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	s = site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()), site.WithMetricsPath("/ops/metrics"))
+	if err := s.RegisterHandlers(&mockHandler{name: "ops", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if err := s.Mount(http.NewServeMux()); err == nil || !strings.Contains(err.Error(), `handler "ops": name conflicts with the site route /ops`) {
+		t.Errorf("Mount should reject a handler shadowing the metrics path, got %v", err)
+	}
+}
+
+func TestMountDefaultRouteFallback(t *testing.T) {
+	t.Parallel()
+	s := site.New(site.WithDevMode(true), site.WithOutputDir(t.TempDir()))
+	if err := s.RegisterHandlers(&mockHandler{name: "val-users", html: "<div>Users</div>", role: '*'}, &mockHandler{name: "val-about", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if err := s.Mount(http.NewServeMux()); err != nil {
+		t.Fatalf("Mount without SetDefaultRoute failed: %v", err)
+	}
+	if got := site.TestDefaultRoute(s); got != "val-users" {
+		t.Errorf("default route = %q, want the first registered module", got)
+	}
+
+	s = site.New()
+	if err := s.RegisterHandlers(&mockHandler{name: "val-about", role: '*'}, &mockHandler{name: "home", role: '*'}); err != nil {
+		t.Fatalf("RegisterHandlers failed: %v", err)
+	}
+	if got := site.TestDefaultRoute(s); got != "home" {
+		t.Errorf("default route = %q, want home when registered", got)
+	}
+}
@@ -0,0 +1,101 @@
+package site
+
+import (
+	"reflect"
+)
+
+// assetRoutes lists the paths served by assetmin, the wasm client handler
+// and the generated sitemap files.
+var assetRoutes = []string{"/", "/style.css", "/script.js", "/icons.svg", "/favicon.svg", "/client.wasm", "/sitemap.xml", "/robots.txt"}
+
+// validate returns problems plus those of the configuration and the
+// registered handlers as one *ConfigError, or nil.
+func (s *Site) validate(problems ...string) error {
+	problems = append(problems, s.validateConfig()...)
+	problems = append(problems, s.validateHandlers()...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ConfigError{Problems: problems}
+}
+
+// validateHandlers reports handlers without a name, names registered by two
+// different handlers, names that are not URL-safe and names taken by the
+// routes the site serves itself.
+func (s *Site) validateHandlers() []string {
+	var problems []string
+	for _, h := range s.handler.unnamed {
+		problems = append(problems, "handler "+typeName(h)+": HandlerName() is missing or empty")
+	}
+	reserved := s.reservedRoutes()
+	seen := make(map[string]any)
+	for _, h := range s.handler.handlers {
+		name := h.(interface{ HandlerName() string }).HandlerName()
+		if first, ok := seen[name]; ok {
+			switch {
+			case sameHandler(first, h):
+			case typeName(first) == typeName(h):
+				problems = append(problems, "handler \""+name+"\": registered twice by different "+typeName(h)+" values")
+			default:
+				problems = append(problems, "handler \""+name+"\": registered by both "+typeName(first)+" and "+typeName(h))
+			}
+			continue
+		}
+		seen[name] = h
+		if !urlSafe(name) {
+			problems = append(problems, "handler \""+name+"\": name is not URL-safe (use letters, digits, \"-\", \"_\", \".\" and \"~\")")
+		}
+		for _, route := range reserved {
+			if route == "/"+name {
+				problems = append(problems, "handler \""+name+"\": name conflicts with the site route "+route)
+			}
+		}
+	}
+	return problems
+}
+
+// reservedRoutes are the first path segments the site serves itself:
+// assets, the crudp batch endpoint, the CSRF token endpoint and, when
+// enabled, the probes and the metrics endpoint.
+func (s *Site) reservedRoutes() []string {
+	routes := append([]string{"/batch", "/__site"}, assetRoutes[1:]...)
+	if s.config.HealthEndpoints {
+		routes = append(routes, "/healthz", "/readyz", "/version")
+	}
+	if p := s.config.MetricsPath; len(p) > 1 {
+		end := 1
+		for end < len(p) && p[end] != '/' {
+			end++
+		}
+		routes = append(routes, p[:end])
+	}
+	return routes
+}
+
+// urlSafe reports whether name only uses RFC 3986 unreserved characters,
+// so #name/params and /name/... routes need no escaping.
+func urlSafe(name string) bool {
+	if name == "." || name == ".." {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~') {
+			return false
+		}
+	}
+	return true
+}
+
+func typeName(c any) string {
+	if c == nil {
+		return "<nil>"
+	}
+	return reflect.TypeOf(c).String()
+}
+
+// sameHandler reports whether a and b are the same handler registered twice.
+func sameHandler(a, b any) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}